@@ -3,21 +3,51 @@ package main
 import (
 	"auth-service/internal/config"
 	"auth-service/internal/handlers"
+	"auth-service/internal/jobs"
 	"auth-service/internal/repository"
+	"encoding/json"
+	"flag"
 	"log"
+	"os"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
 
 func main() {
+	purgar := flag.Bool("purge", false, "purga los registros eliminados fuera del periodo de retención y termina")
+	dryRun := flag.Bool("dry-run", false, "con -purge, informa lo que se purgaría sin eliminar nada")
+	flag.Parse()
+
+	retencionDias, err := config.RetencionEliminadosDias()
+	if err != nil {
+		log.Fatalf("Invalid retention period: %v", err)
+	}
+
 	// Setup database connection
 	db, err := config.SetupDatabase()
 	if err != nil {
 		log.Fatalf("Failed to setup database: %v", err)
 	}
+	if err := config.SeedOrganizacion(db); err != nil {
+		log.Fatalf("Failed to seed organizacion: %v", err)
+	}
 	if err := config.SeedPermisos(db); err != nil {
 		log.Fatalf("Failed to seed permisos: %v", err)
 	}
+	if err := config.SeedTiposDocumento(db); err != nil {
+		log.Fatalf("Failed to seed tipos de documento: %v", err)
+	}
+	if err := config.SeedModuleCodes(db); err != nil {
+		log.Fatalf("Failed to seed module codes: %v", err)
+	}
+	if err := config.MigrateSedesRegionales(db); err != nil {
+		log.Fatalf("Failed to migrate sedes and regionales: %v", err)
+	}
+	// Aislar los datos de cada organización
+	if err := repository.RegisterOrganizacionScope(db); err != nil {
+		log.Fatalf("Failed to register organizacion scope: %v", err)
+	}
 
 	// Initialize repositories
 	roleRepo := repository.NewRoleRepository(db)
@@ -25,18 +55,65 @@ func main() {
 	moduleRepo := repository.NewModuleRepository(db)
 	userRepo := repository.NewUserRepository(db)
 	rolModuloPermisoRepo := repository.NewRolModuloPermisoRepository(db)
+	authorizationRepo := repository.NewAuthorizationRepository(db)
+	elevacionRepo := repository.NewElevacionRepository(db)
+	sodRepo := repository.NewSoDRepository(db)
+	recursoPermisoRepo := repository.NewRecursoPermisoRepository(db)
+	delegacionRepo := repository.NewDelegacionRepository(db)
+	grupoRepo := repository.NewGrupoRepository(db)
+	plantillaRolRepo := repository.NewPlantillaRolRepository(db)
+	purgaRepo := repository.NewPurgaRepository(db)
+	organizacionRepo := repository.NewOrganizacionRepository(db)
+	regionalRepo := repository.NewRegionalRepository(db)
+	sedeRepo := repository.NewSedeRepository(db)
+	tipoDocumentoRepo := repository.NewTipoDocumentoRepository(db)
+
+	// Purga bajo demanda desde la línea de comandos
+	if *purgar {
+		reporte, err := purgaRepo.Purge(retencionDias, *dryRun)
+		if err != nil {
+			log.Fatalf("Failed to purge deleted records: %v", err)
+		}
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(reporte); err != nil {
+			log.Fatalf("Failed to write purge report: %v", err)
+		}
+		return
+	}
 
 	// Initialize handlers
-	roleHandler := handlers.NewRoleHandler(roleRepo, rolModuloPermisoRepo)
+	roleHandler := handlers.NewRoleHandler(roleRepo, rolModuloPermisoRepo, moduleRepo)
 	permisoTipoHandler := handlers.NewPermisoTipoHandler(permisoTipoRepo)
 	moduleHandler := handlers.NewModuleHandler(moduleRepo)
 	userHandler := handlers.NewUserHandler(userRepo, roleRepo)
+	authorizationHandler := handlers.NewAuthorizationHandler(authorizationRepo)
+	elevacionHandler := handlers.NewElevacionHandler(elevacionRepo)
+	sodHandler := handlers.NewSoDHandler(sodRepo)
+	recursoPermisoHandler := handlers.NewRecursoPermisoHandler(recursoPermisoRepo)
+	delegacionHandler := handlers.NewDelegacionHandler(delegacionRepo)
+	grupoHandler := handlers.NewGrupoHandler(grupoRepo)
+	plantillaRolHandler := handlers.NewPlantillaRolHandler(plantillaRolRepo)
+	purgaHandler := handlers.NewPurgaHandler(purgaRepo, retencionDias)
+	organizacionHandler := handlers.NewOrganizacionHandler(organizacionRepo)
+	regionalHandler := handlers.NewRegionalHandler(regionalRepo)
+	sedeHandler := handlers.NewSedeHandler(sedeRepo)
+	tipoDocumentoHandler := handlers.NewTipoDocumentoHandler(tipoDocumentoRepo)
+
+	// Eliminar periódicamente los permisos vencidos y expirar elevaciones
+	jobs.NewGrantSweeper(rolModuloPermisoRepo, elevacionRepo, time.Minute).Start()
+
+	// Purgar diariamente los registros eliminados fuera del periodo de retención
+	jobs.NewRetentionPurger(purgaRepo, retencionDias, 24*time.Hour).Start()
 
 	// Setup Gin router
 	r := gin.Default()
 
+	// Las rutas de datos de una organización se limitan a la organización de la petición
+	api := r.Group("", organizacionHandler.ResolverOrganizacion)
+
 	// User routes
-	userRoutes := r.Group("/users")
+	userRoutes := api.Group("/users")
 	{
 		userRoutes.POST("", userHandler.Create)
 		userRoutes.GET("", userHandler.GetAll)
@@ -44,44 +121,182 @@ func main() {
 		userRoutes.PUT("/:id", userHandler.Update)                   // Actualización general
 		userRoutes.POST("/:id/password", userHandler.ChangePassword) // Cambio de contraseña
 		userRoutes.DELETE("/:id", userHandler.Delete)
+		userRoutes.POST("/:id/restore", userHandler.Restore)
+		userRoutes.POST("/:id/status", userHandler.ChangeEstado)
+		userRoutes.GET("/:id/status-history", userHandler.GetEstadoHistorial)
+		userRoutes.GET("/deleted", userHandler.GetDeletedUsers)
 		userRoutes.GET("/permissions", userHandler.GetAllUsersWithPermissions)
 		userRoutes.GET("/:id/permissions", userHandler.GetUserPermissions)
 	}
 
 	// Role routes
-	roleRoutes := r.Group("/roles")
+	roleRoutes := api.Group("/roles")
 	{
 		roleRoutes.POST("", roleHandler.Create)
 		roleRoutes.GET("", roleHandler.GetAll)
 		roleRoutes.POST("/assign-permission", roleHandler.AssignModulePermission)
+		roleRoutes.GET("/deleted", roleHandler.GetDeletedRoles)
+		roleRoutes.POST("/:id/restore", roleHandler.Restore)
+		roleRoutes.GET("/:id", roleHandler.GetByID)
+		roleRoutes.PUT("/:id", roleHandler.Update)
+		roleRoutes.DELETE("/:id", roleHandler.Delete)
 		roleRoutes.GET("/:id/permissions", roleHandler.GetRolePermissions)
 		roleRoutes.DELETE("/remove-permission", roleHandler.RemoveModulePermission)
 		// Nueva ruta para eliminar un módulo completo de un rol
 		roleRoutes.DELETE("/remove-module", roleHandler.RemoveModuleFromRole)
+		// Herencia de roles
+		roleRoutes.GET("/:id/parents", roleHandler.GetParents)
+		roleRoutes.POST("/:id/parents", roleHandler.AddParent)
+		roleRoutes.DELETE("/:id/parents/:parentId", roleHandler.RemoveParent)
+		roleRoutes.POST("/:id/clone", roleHandler.Clone)
+		roleRoutes.GET("/inconsistent-grants", roleHandler.GetInconsistentGrants)
+		roleRoutes.POST("/inconsistent-grants/repair", roleHandler.RepairInconsistentGrants)
 	}
 
-	// Permiso Tipo routes
+	// Permiso Tipo routes (catálogo global: solo la plataforma lo modifica)
 	permisoTipoRoutes := r.Group("/permiso-tipos")
 	{
-		permisoTipoRoutes.POST("", permisoTipoHandler.Create)
+		permisoTipoRoutes.POST("", organizacionHandler.SoloPlataforma, permisoTipoHandler.Create)
 		permisoTipoRoutes.GET("", permisoTipoHandler.GetAll)
 		permisoTipoRoutes.GET("/:id", permisoTipoHandler.GetByID)
+		permisoTipoRoutes.PUT("/:id", organizacionHandler.SoloPlataforma, permisoTipoHandler.Update)
+		permisoTipoRoutes.DELETE("/:id", organizacionHandler.SoloPlataforma, permisoTipoHandler.Retire)
 	}
 
 	// Module routes
-	moduleRoutes := r.Group("/modules")
+	moduleRoutes := api.Group("/modules")
 	{
 		moduleRoutes.POST("", moduleHandler.Create)
 		moduleRoutes.GET("", moduleHandler.GetAll)
 		moduleRoutes.GET("/:id/permissions", moduleHandler.GetModuleWithPermissions)
+		moduleRoutes.PUT("/:id/parent", moduleHandler.SetParent)
 		moduleRoutes.POST("/assign-permissions", moduleHandler.AssignPermissions)
 		// Nuevas rutas para módulos
 		moduleRoutes.DELETE("/:id", moduleHandler.Delete)
 		moduleRoutes.DELETE("/remove-permission", moduleHandler.RemovePermission)
 		moduleRoutes.POST("/:id/restore", moduleHandler.Restore)
+		moduleRoutes.GET("/:id/restore-preview", moduleHandler.PreviewRestore)
 		moduleRoutes.GET("/deleted", moduleHandler.GetDeletedModules)
 	}
 
+	// Resource-instance grant routes
+	resourceGrantRoutes := api.Group("/resource-grants")
+	{
+		resourceGrantRoutes.POST("", recursoPermisoHandler.Create)
+		resourceGrantRoutes.GET("", recursoPermisoHandler.GetAll)
+		resourceGrantRoutes.GET("/:id", recursoPermisoHandler.GetByID)
+		resourceGrantRoutes.PUT("/:id", recursoPermisoHandler.Update)
+		resourceGrantRoutes.DELETE("/:id", recursoPermisoHandler.Delete)
+	}
+
+	// Authorization routes
+	authorizationRoutes := api.Group("/authorization")
+	{
+		authorizationRoutes.POST("/check", authorizationHandler.Check)
+	}
+
+	// Elevation routes
+	elevationRoutes := api.Group("/elevations")
+	{
+		elevationRoutes.POST("", elevacionHandler.Create)
+		elevationRoutes.GET("", elevacionHandler.GetAll)
+		elevationRoutes.GET("/:id", elevacionHandler.GetByID)
+		elevationRoutes.POST("/:id/approve", elevacionHandler.Approve)
+		elevationRoutes.POST("/:id/reject", elevacionHandler.Reject)
+		elevationRoutes.GET("/approvers", elevacionHandler.GetAprobadores)
+		elevationRoutes.POST("/approvers", elevacionHandler.CreateAprobador)
+		elevationRoutes.DELETE("/approvers/:id", elevacionHandler.DeleteAprobador)
+	}
+
+	// Delegation routes
+	delegationRoutes := api.Group("/delegations")
+	{
+		delegationRoutes.POST("", delegacionHandler.Create)
+		delegationRoutes.GET("", delegacionHandler.GetAll)
+		delegationRoutes.GET("/:id", delegacionHandler.GetByID)
+		delegationRoutes.POST("/:id/revoke", delegacionHandler.Revoke)
+	}
+
+	// Role template routes
+	roleTemplateRoutes := api.Group("/role-templates")
+	{
+		roleTemplateRoutes.POST("", plantillaRolHandler.Create)
+		roleTemplateRoutes.GET("", plantillaRolHandler.GetAll)
+		roleTemplateRoutes.GET("/:id", plantillaRolHandler.GetByID)
+		roleTemplateRoutes.DELETE("/:id", plantillaRolHandler.Delete)
+		roleTemplateRoutes.POST("/:id/instantiate", plantillaRolHandler.Instantiate)
+	}
+
+	// Group routes
+	groupRoutes := api.Group("/groups")
+	{
+		groupRoutes.POST("", grupoHandler.Create)
+		groupRoutes.GET("", grupoHandler.GetAll)
+		groupRoutes.GET("/:id", grupoHandler.GetByID)
+		groupRoutes.PUT("/:id", grupoHandler.Update)
+		groupRoutes.DELETE("/:id", grupoHandler.Delete)
+		groupRoutes.GET("/:id/members", grupoHandler.GetMembers)
+		groupRoutes.POST("/:id/members", grupoHandler.AddMember)
+		groupRoutes.DELETE("/:id/members/:userId", grupoHandler.RemoveMember)
+		groupRoutes.POST("/:id/roles", grupoHandler.AddRole)
+		groupRoutes.DELETE("/:id/roles/:roleId", grupoHandler.RemoveRole)
+		groupRoutes.GET("/:id/permissions", grupoHandler.GetPermissions)
+		groupRoutes.POST("/:id/permissions", grupoHandler.AssignPermissions)
+		groupRoutes.DELETE("/:id/permissions", grupoHandler.RemovePermission)
+	}
+
+	// Separation-of-duties routes
+	sodRoutes := api.Group("/sod")
+	{
+		sodRoutes.POST("/constraints", sodHandler.Create)
+		sodRoutes.GET("/constraints", sodHandler.GetAll)
+		sodRoutes.DELETE("/constraints/:id", sodHandler.Delete)
+		sodRoutes.GET("/violations", sodHandler.GetViolations)
+	}
+
+	// Regional and sede catalog routes
+	regionalRoutes := api.Group("/regionales")
+	{
+		regionalRoutes.POST("", regionalHandler.Create)
+		regionalRoutes.GET("", regionalHandler.GetAll)
+		regionalRoutes.GET("/:id", regionalHandler.GetByID)
+		regionalRoutes.PUT("/:id", regionalHandler.Update)
+		regionalRoutes.DELETE("/:id", regionalHandler.Delete)
+	}
+	sedeRoutes := api.Group("/sedes")
+	{
+		sedeRoutes.POST("", sedeHandler.Create)
+		sedeRoutes.GET("", sedeHandler.GetAll)
+		sedeRoutes.GET("/:id", sedeHandler.GetByID)
+		sedeRoutes.PUT("/:id", sedeHandler.Update)
+		sedeRoutes.DELETE("/:id", sedeHandler.Delete)
+	}
+
+	// Maintenance routes
+	adminRoutes := api.Group("/admin")
+	{
+		adminRoutes.POST("/purge", purgaHandler.Purge)
+	}
+
+	// Organization routes (solo la organización de la plataforma)
+	organizationRoutes := api.Group("/organizations", organizacionHandler.SoloPlataforma)
+	{
+		organizationRoutes.POST("", organizacionHandler.Create)
+		organizationRoutes.GET("", organizacionHandler.GetAll)
+		organizationRoutes.GET("/:id", organizacionHandler.GetByID)
+		organizationRoutes.PUT("/:id", organizacionHandler.Update)
+	}
+
+	// Document type routes (catálogo compartido por todas las organizaciones;
+	// solo la plataforma lo modifica)
+	tipoDocumentoRoutes := r.Group("/tipos-documento")
+	{
+		tipoDocumentoRoutes.POST("", organizacionHandler.SoloPlataforma, tipoDocumentoHandler.Create)
+		tipoDocumentoRoutes.GET("", tipoDocumentoHandler.GetAll)
+		tipoDocumentoRoutes.GET("/:id", tipoDocumentoHandler.GetByID)
+		tipoDocumentoRoutes.PUT("/:id", organizacionHandler.SoloPlataforma, tipoDocumentoHandler.Update)
+	}
+
 	// Start server
 	if err := r.Run(":8080"); err != nil {
 		log.Fatalf("Failed to start server: %v", err)
@@ -41,14 +41,40 @@ func SetupDatabase() (*gorm.DB, error) {
         $$ LANGUAGE plpgsql;
     `)
 
+	// Los nombres de rol, correos y documentos pasan a ser únicos por
+	// organización; se eliminan las restricciones globales anteriores
+	db.Exec("ALTER TABLE IF EXISTS testing.roles DROP CONSTRAINT IF EXISTS roles_nombre_key")
+	db.Exec("ALTER TABLE IF EXISTS testing.usuarios DROP CONSTRAINT IF EXISTS usuarios_numero_documento_key")
+	db.Exec("ALTER TABLE IF EXISTS testing.usuarios DROP CONSTRAINT IF EXISTS usuarios_correo_key")
+
 	// Automigrate the models
 	if err := db.AutoMigrate(
+		&models.Organizacion{},
 		&models.Role{},
 		&models.PermisoTipo{},
 		&models.Module{},
 		&models.ModuloPermiso{},
 		&models.RolModuloPermiso{},
+		&models.RolModuloPermisoAlcance{},
+		&models.Regional{},
+		&models.Sede{},
+		&models.TipoDocumento{},
 		&models.User{},
+		&models.RolHerencia{},
+		&models.SolicitudElevacion{},
+		&models.AprobadorElevacion{},
+		&models.RestriccionSoD{},
+		&models.RecursoPermiso{},
+		&models.Delegacion{},
+		&models.DelegacionPermiso{},
+		&models.Grupo{},
+		&models.GrupoUsuario{},
+		&models.GrupoRol{},
+		&models.GrupoModuloPermiso{},
+		&models.PlantillaRol{},
+		&models.PlantillaRolPermiso{},
+		&models.LoteEliminacion{},
+		&models.UsuarioEstadoHistorial{},
 	); err != nil {
 		return nil, fmt.Errorf("failed to migrate database: %v", err)
 	}
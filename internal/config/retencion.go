@@ -0,0 +1,26 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+)
+
+// RetencionEliminadosDiasDefecto es el periodo de retención de los registros
+// eliminados lógicamente cuando no se configura otro
+const RetencionEliminadosDiasDefecto = 90
+
+// RetencionEliminadosDias devuelve cuántos días se conservan los registros
+// eliminados lógicamente antes de purgarlos, configurable con la variable de
+// entorno RETENCION_ELIMINADOS_DIAS
+func RetencionEliminadosDias() (int, error) {
+	valor := os.Getenv("RETENCION_ELIMINADOS_DIAS")
+	if valor == "" {
+		return RetencionEliminadosDiasDefecto, nil
+	}
+	dias, err := strconv.Atoi(valor)
+	if err != nil || dias < 1 {
+		return 0, fmt.Errorf("RETENCION_ELIMINADOS_DIAS debe ser un número entero de días mayor que cero: %q", valor)
+	}
+	return dias, nil
+}
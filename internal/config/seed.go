@@ -2,6 +2,9 @@ package config
 
 import (
 	"auth-service/internal/models"
+	"fmt"
+	"log"
+	"strings"
 
 	"gorm.io/gorm"
 )
@@ -42,3 +45,248 @@ func SeedPermisos(db *gorm.DB) error {
 	}
 	return nil
 }
+
+// SeedModuleCodes asigna un código a los módulos creados antes de que
+// existiera la columna, derivado de su nombre
+func SeedModuleCodes(db *gorm.DB) error {
+	var modules []models.Module
+	if err := db.Where("codigo IS NULL OR codigo = ''").Order("id").Find(&modules).Error; err != nil {
+		return err
+	}
+
+	for _, module := range modules {
+		codigo := models.CodigoDesdeNombre(module.Nombre)
+		if codigo == "" {
+			codigo = "modulo"
+		}
+
+		var exists bool
+		if err := db.Model(&models.Module{}).
+			Where("id_organizacion = ? AND codigo = ?", module.IdOrganizacion, codigo).
+			Select("count(*) > 0").
+			Scan(&exists).Error; err != nil {
+			return err
+		}
+		if exists {
+			codigo = models.CodigoConSufijo(codigo, fmt.Sprintf("-%d", module.ID))
+		}
+
+		if err := db.Model(&module).Update("codigo", codigo).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SeedOrganizacion crea la organización por defecto, a la que pertenecen los
+// registros creados antes de que existieran las organizaciones
+func SeedOrganizacion(db *gorm.DB) error {
+	var exists bool
+	if err := db.Model(&models.Organizacion{}).
+		Where("id = ?", models.OrganizacionPorDefecto).
+		Select("count(*) > 0").
+		Scan(&exists).Error; err != nil {
+		return err
+	}
+	if exists {
+		return nil
+	}
+
+	organizacion := models.Organizacion{
+		ID:     models.OrganizacionPorDefecto,
+		Codigo: "default",
+		Nombre: "Organización por defecto",
+		Activa: true,
+	}
+	if err := db.Create(&organizacion).Error; err != nil {
+		return err
+	}
+	// El ID se indicó explícitamente; la secuencia debe continuar después de él
+	return db.Exec("SELECT setval(pg_get_serial_sequence('testing.organizaciones', 'id'), (SELECT MAX(id) FROM testing.organizaciones))").Error
+}
+
+// MigrateSedesRegionales asocia a los usuarios que aún no tienen sede del
+// catálogo las sedes y regionales que corresponden a sus nombres, creando las
+// que falten. Las variantes de un mismo nombre ("Bogotá", "Bogota", "BOGOTA")
+// se unifican en una sola fila con la grafía más frecuente. Los valores de los
+// alcances por sede o regional se reemplazan por los nombres del catálogo.
+func MigrateSedesRegionales(db *gorm.DB) error {
+	type ubicacion struct {
+		IdOrganizacion int
+		Regional       string
+		Sede           string
+		Total          int
+	}
+	var ubicaciones []ubicacion
+	if err := db.Model(&models.User{}).
+		Select("id_organizacion, regional, sede, count(*) AS total").
+		Where("id_sede IS NULL AND TRIM(sede) <> '' AND TRIM(regional) <> ''").
+		Group("id_organizacion, regional, sede").
+		Order("total DESC, regional, sede").
+		Scan(&ubicaciones).Error; err != nil {
+		return err
+	}
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		for _, u := range ubicaciones {
+			regional := models.Regional{
+				IdOrganizacion: u.IdOrganizacion,
+				Nombre:         models.NombreCatalogo(u.Regional),
+				Clave:          models.ClaveCatalogo(u.Regional),
+			}
+			if err := tx.Where("id_organizacion = ? AND clave = ?", regional.IdOrganizacion, regional.Clave).
+				FirstOrCreate(&regional).Error; err != nil {
+				return err
+			}
+
+			sede := models.Sede{
+				IdOrganizacion: u.IdOrganizacion,
+				IdRegional:     regional.ID,
+				Nombre:         models.NombreCatalogo(u.Sede),
+				Clave:          models.ClaveCatalogo(u.Sede),
+			}
+			if err := tx.Where("id_regional = ? AND clave = ?", sede.IdRegional, sede.Clave).
+				FirstOrCreate(&sede).Error; err != nil {
+				return err
+			}
+
+			if err := tx.Model(&models.User{}).
+				Where("id_sede IS NULL AND id_organizacion = ? AND regional = ? AND sede = ?",
+					u.IdOrganizacion, u.Regional, u.Sede).
+				Updates(map[string]interface{}{
+					"id_sede":     sede.ID,
+					"sede":        sede.Nombre,
+					"id_regional": regional.ID,
+					"regional":    regional.Nombre,
+				}).Error; err != nil {
+				return err
+			}
+		}
+
+		return migrateValoresAlcance(tx)
+	})
+}
+
+// migrateValoresAlcance reemplaza los valores de los alcances por sede o
+// regional que coinciden con el catálogo por el nombre del catálogo
+func migrateValoresAlcance(tx *gorm.DB) error {
+	type valorAlcance struct {
+		ID             int
+		Valor          string
+		Alcance        string
+		IdOrganizacion int
+	}
+	var valores []valorAlcance
+	if err := tx.Model(&models.RolModuloPermisoAlcance{}).
+		Select("rol_modulo_permiso_alcances.id, rol_modulo_permiso_alcances.valor, rol_modulo_permisos.alcance, rol_modulo_permisos.id_organizacion").
+		Joins("JOIN rol_modulo_permisos ON rol_modulo_permisos.id = rol_modulo_permiso_alcances.id_rol_modulo_permiso").
+		Where("rol_modulo_permisos.alcance IN ?", []string{models.AlcanceSede, models.AlcanceRegional}).
+		Scan(&valores).Error; err != nil {
+		return err
+	}
+
+	for _, v := range valores {
+		var model interface{} = &models.Regional{}
+		if v.Alcance == models.AlcanceSede {
+			model = &models.Sede{}
+		}
+		var nombres []string
+		if err := tx.Model(model).
+			Where("id_organizacion = ? AND clave = ?", v.IdOrganizacion, models.ClaveCatalogo(v.Valor)).
+			Limit(1).
+			Pluck("nombre", &nombres).Error; err != nil {
+			return err
+		}
+		if len(nombres) == 0 || nombres[0] == v.Valor {
+			continue
+		}
+		if err := tx.Model(&models.RolModuloPermisoAlcance{}).
+			Where("id = ?", v.ID).
+			Update("valor", nombres[0]).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SeedTiposDocumento crea los tipos de documento base que falten y reemplaza
+// los tipos guardados en los usuarios, que antes eran texto libre, por su
+// código del catálogo. Los valores que no corresponden a ningún código se
+// informan en el log: esos usuarios no pasarán la validación del documento
+// hasta que se corrija su tipo.
+func SeedTiposDocumento(db *gorm.DB) error {
+	tipos := []models.TipoDocumento{
+		{Codigo: models.DocumentoCedulaCiudadania, Nombre: "Cédula de ciudadanía", Patron: `^[0-9]+$`, LongitudMinima: 3, LongitudMaxima: 10},
+		{Codigo: models.DocumentoCedulaExtranjeria, Nombre: "Cédula de extranjería", Patron: `^[0-9]+$`, LongitudMinima: 6, LongitudMaxima: 10},
+		{Codigo: models.DocumentoTarjetaIdentidad, Nombre: "Tarjeta de identidad", Patron: `^[0-9]+$`, LongitudMinima: 10, LongitudMaxima: 11},
+		{Codigo: models.DocumentoPasaporte, Nombre: "Pasaporte", Patron: `^[A-Z0-9]+$`, LongitudMinima: 5, LongitudMaxima: 20},
+		{Codigo: models.DocumentoNIT, Nombre: "NIT", Patron: `^[0-9]+$`, LongitudMinima: 6, LongitudMaxima: 16, DigitoVerificacion: true},
+		{Codigo: models.DocumentoPEP, Nombre: "Permiso especial de permanencia", Patron: `^[0-9]+$`, LongitudMinima: 15, LongitudMaxima: 15},
+	}
+
+	for _, tipo := range tipos {
+		var exists models.TipoDocumento
+		if err := db.Where("codigo = ?", tipo.Codigo).First(&exists).Error; err != nil {
+			if err != gorm.ErrRecordNotFound {
+				return err
+			}
+			tipo.Activo = true
+			if err := db.Create(&tipo).Error; err != nil {
+				return err
+			}
+		}
+	}
+
+	var codigos []string
+	if err := db.Model(&models.TipoDocumento{}).Pluck("codigo", &codigos).Error; err != nil {
+		return err
+	}
+	catalogo := make(map[string]bool, len(codigos))
+	for _, codigo := range codigos {
+		catalogo[codigo] = true
+	}
+
+	var valores []string
+	if err := db.Model(&models.User{}).Distinct().Pluck("tipo_documento", &valores).Error; err != nil {
+		return err
+	}
+	for _, valor := range valores {
+		codigo := strings.ToUpper(strings.TrimSpace(valor))
+		if !catalogo[codigo] {
+			legado, ok := models.CodigoTipoDocumentoLegado(valor)
+			if !ok {
+				continue
+			}
+			codigo = legado
+		}
+		if codigo == valor {
+			continue
+		}
+		// Se omiten los usuarios que ya tienen el mismo número con el código;
+		// cambiarlos duplicaría el documento y quedan como no migrados
+		if err := db.Model(&models.User{}).
+			Where("tipo_documento = ?", valor).
+			Where("NOT EXISTS (SELECT 1 FROM testing.usuarios AS u WHERE u.id_organizacion = usuarios.id_organizacion AND u.tipo_documento = ? AND u.numero_documento = usuarios.numero_documento)", codigo).
+			Update("tipo_documento", codigo).Error; err != nil {
+			return err
+		}
+	}
+
+	type pendiente struct {
+		TipoDocumento string
+		Total         int
+	}
+	var pendientes []pendiente
+	if err := db.Model(&models.User{}).
+		Select("tipo_documento, count(*) AS total").
+		Where("tipo_documento NOT IN ?", codigos).
+		Group("tipo_documento").
+		Order("tipo_documento").
+		Scan(&pendientes).Error; err != nil {
+		return err
+	}
+	for _, p := range pendientes {
+		log.Printf("Tipo de documento sin código en el catálogo: %q (%d usuarios)", p.TipoDocumento, p.Total)
+	}
+	return nil
+}
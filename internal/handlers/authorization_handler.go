@@ -0,0 +1,33 @@
+package handlers
+
+import (
+	"auth-service/internal/models"
+	"auth-service/internal/repository"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type AuthorizationHandler struct {
+	repo *repository.AuthorizationRepository
+}
+
+func NewAuthorizationHandler(repo *repository.AuthorizationRepository) *AuthorizationHandler {
+	return &AuthorizationHandler{repo: repo}
+}
+
+func (h *AuthorizationHandler) Check(c *gin.Context) {
+	var req models.AuthorizationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	response, err := h.repo.WithContext(c.Request.Context()).Check(req)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
@@ -0,0 +1,124 @@
+package handlers
+
+import (
+	"auth-service/internal/models"
+	"auth-service/internal/repository"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type DelegacionHandler struct {
+	repo *repository.DelegacionRepository
+}
+
+func NewDelegacionHandler(repo *repository.DelegacionRepository) *DelegacionHandler {
+	return &DelegacionHandler{repo: repo}
+}
+
+func (h *DelegacionHandler) Create(c *gin.Context) {
+	var req models.CreateDelegacionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	fechaInicio := time.Now()
+	if req.FechaInicio != nil {
+		fechaInicio = *req.FechaInicio
+	}
+
+	delegacion := &models.Delegacion{
+		IdDelegante: req.IdDelegante,
+		IdDelegado:  req.IdDelegado,
+		Todos:       req.Todos,
+		Motivo:      req.Motivo,
+		FechaInicio: fechaInicio,
+		FechaFin:    req.FechaFin,
+	}
+	for _, p := range req.Permisos {
+		delegacion.Permisos = append(delegacion.Permisos, models.DelegacionPermiso{
+			IdModulo:      p.ModuloID,
+			IdPermisoTipo: p.PermisoTipoID,
+		})
+	}
+
+	if err := h.repo.WithContext(c.Request.Context()).Create(delegacion); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	created, err := h.repo.WithContext(c.Request.Context()).GetByID(delegacion.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, created)
+}
+
+func (h *DelegacionHandler) GetAll(c *gin.Context) {
+	filter := models.DelegacionFilter{
+		Activas: c.Query("activas") == "true",
+	}
+	for param, target := range map[string]*int{
+		"id_delegante": &filter.IdDelegante,
+		"id_delegado":  &filter.IdDelegado,
+	} {
+		if value := c.Query(param); value != "" {
+			id, err := strconv.Atoi(value)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Parámetro inválido: " + param})
+				return
+			}
+			*target = id
+		}
+	}
+
+	delegaciones, err := h.repo.WithContext(c.Request.Context()).GetAll(filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, delegaciones)
+}
+
+func (h *DelegacionHandler) GetByID(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+
+	delegacion, err := h.repo.WithContext(c.Request.Context()).GetByID(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, delegacion)
+}
+
+func (h *DelegacionHandler) Revoke(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+
+	var req models.RevokeDelegacionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.repo.WithContext(c.Request.Context()).Revoke(id, req.Motivo); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Delegación revocada exitosamente"})
+}
@@ -0,0 +1,180 @@
+package handlers
+
+import (
+	"auth-service/internal/models"
+	"auth-service/internal/repository"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ElevacionHandler struct {
+	repo *repository.ElevacionRepository
+}
+
+func NewElevacionHandler(repo *repository.ElevacionRepository) *ElevacionHandler {
+	return &ElevacionHandler{repo: repo}
+}
+
+func (h *ElevacionHandler) Create(c *gin.Context) {
+	var req models.CreateElevacionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	solicitud := &models.SolicitudElevacion{
+		IdUsuario:       req.IdUsuario,
+		IdModulo:        req.ModuloID,
+		IdPermisoTipo:   req.PermisoTipoID,
+		DuracionMinutos: req.DuracionMinutos,
+		Justificacion:   req.Justificacion,
+	}
+
+	if err := h.repo.WithContext(c.Request.Context()).Create(solicitud); err != nil {
+		if respondPermisosNoHabilitados(c, err) {
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	created, err := h.repo.WithContext(c.Request.Context()).GetByID(solicitud.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, created)
+}
+
+func (h *ElevacionHandler) GetAll(c *gin.Context) {
+	filter := models.ElevacionFilter{
+		Estado:  c.Query("estado"),
+		Activas: c.Query("activas") == "true",
+	}
+	if idUsuario := c.Query("id_usuario"); idUsuario != "" {
+		id, err := strconv.Atoi(idUsuario)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "ID de usuario inválido"})
+			return
+		}
+		filter.IdUsuario = id
+	}
+	if idModulo := c.Query("id_modulo"); idModulo != "" {
+		id, err := strconv.Atoi(idModulo)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "ID de módulo inválido"})
+			return
+		}
+		filter.IdModulo = id
+	}
+
+	solicitudes, err := h.repo.WithContext(c.Request.Context()).GetAll(filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, solicitudes)
+}
+
+func (h *ElevacionHandler) GetByID(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+
+	solicitud, err := h.repo.WithContext(c.Request.Context()).GetByID(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, solicitud)
+}
+
+func (h *ElevacionHandler) Approve(c *gin.Context) {
+	h.decide(c, h.repo.WithContext(c.Request.Context()).Approve, "Solicitud aprobada; el permiso está activo")
+}
+
+func (h *ElevacionHandler) Reject(c *gin.Context) {
+	h.decide(c, h.repo.WithContext(c.Request.Context()).Reject, "Solicitud rechazada")
+}
+
+func (h *ElevacionHandler) decide(c *gin.Context, decision func(id, aprobadorID int, motivo string) error, message string) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+
+	var req models.DecisionElevacionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := decision(id, req.IdAprobador, req.Motivo); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	solicitud, err := h.repo.WithContext(c.Request.Context()).GetByID(id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":   message,
+		"solicitud": solicitud,
+	})
+}
+
+func (h *ElevacionHandler) CreateAprobador(c *gin.Context) {
+	var req models.CreateAprobadorElevacionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	aprobador := &models.AprobadorElevacion{
+		IdUsuario: req.IdUsuario,
+		IdModulo:  req.ModuloID,
+	}
+
+	if err := h.repo.WithContext(c.Request.Context()).CreateAprobador(aprobador); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, aprobador)
+}
+
+func (h *ElevacionHandler) GetAprobadores(c *gin.Context) {
+	aprobadores, err := h.repo.WithContext(c.Request.Context()).GetAprobadores()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, aprobadores)
+}
+
+func (h *ElevacionHandler) DeleteAprobador(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+
+	if err := h.repo.WithContext(c.Request.Context()).DeleteAprobador(id); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Aprobador eliminado exitosamente"})
+}
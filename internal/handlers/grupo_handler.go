@@ -0,0 +1,301 @@
+package handlers
+
+import (
+	"auth-service/internal/models"
+	"auth-service/internal/repository"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type GrupoHandler struct {
+	repo *repository.GrupoRepository
+}
+
+func NewGrupoHandler(repo *repository.GrupoRepository) *GrupoHandler {
+	return &GrupoHandler{repo: repo}
+}
+
+func (h *GrupoHandler) Create(c *gin.Context) {
+	var req models.CreateGrupoRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	grupo := &models.Grupo{
+		Nombre:      req.Nombre,
+		Descripcion: req.Descripcion,
+	}
+
+	if err := h.repo.WithContext(c.Request.Context()).Create(grupo); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	h.respond(c, http.StatusCreated, grupo.ID)
+}
+
+func (h *GrupoHandler) GetAll(c *gin.Context) {
+	grupos, err := h.repo.WithContext(c.Request.Context()).GetAll()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, grupos)
+}
+
+func (h *GrupoHandler) GetByID(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+
+	h.respond(c, http.StatusOK, id)
+}
+
+func (h *GrupoHandler) Update(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+
+	var req models.CreateGrupoRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	grupo, err := h.repo.WithContext(c.Request.Context()).GetByID(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	grupo.Nombre = req.Nombre
+	grupo.Descripcion = req.Descripcion
+
+	if err := h.repo.WithContext(c.Request.Context()).Update(grupo); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	h.respond(c, http.StatusOK, id)
+}
+
+func (h *GrupoHandler) Delete(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+
+	if err := h.repo.WithContext(c.Request.Context()).Delete(id); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Grupo eliminado exitosamente"})
+}
+
+func (h *GrupoHandler) GetMembers(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+
+	users, err := h.repo.WithContext(c.Request.Context()).GetMembers(id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	response := make([]models.UserResponse, len(users))
+	for i, user := range users {
+		response[i] = newUserResponse(&user)
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+func (h *GrupoHandler) AddMember(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+
+	var req models.GrupoMiembroRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.repo.WithContext(c.Request.Context()).AddMember(id, req.IdUsuario); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Usuario agregado exitosamente al grupo"})
+}
+
+func (h *GrupoHandler) RemoveMember(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+	userID, err := strconv.Atoi(c.Param("userId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID de usuario inválido"})
+		return
+	}
+
+	if err := h.repo.WithContext(c.Request.Context()).RemoveMember(id, userID); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Usuario removido exitosamente del grupo"})
+}
+
+func (h *GrupoHandler) AddRole(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+
+	var req models.GrupoRolRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.repo.WithContext(c.Request.Context()).AddRole(id, req.IdRol); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Rol asignado exitosamente al grupo"})
+}
+
+func (h *GrupoHandler) RemoveRole(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+	roleID, err := strconv.Atoi(c.Param("roleId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID de rol inválido"})
+		return
+	}
+
+	if err := h.repo.WithContext(c.Request.Context()).RemoveRole(id, roleID); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Rol removido exitosamente del grupo"})
+}
+
+func (h *GrupoHandler) AssignPermissions(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+
+	var req models.AssignGrupoPermissionsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.repo.WithContext(c.Request.Context()).AssignModulePermission(id, req); err != nil {
+		if respondPermisosNoHabilitados(c, err) {
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Permisos asignados exitosamente al grupo"})
+}
+
+func (h *GrupoHandler) GetPermissions(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+
+	permisos, err := h.repo.WithContext(c.Request.Context()).GetModulePermissions(id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, permisos)
+}
+
+func (h *GrupoHandler) RemovePermission(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+
+	var req struct {
+		ModuloID      int `json:"modulo_id" binding:"required"`
+		PermisoTipoID int `json:"permiso_tipo_id" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.repo.WithContext(c.Request.Context()).RemoveModulePermission(id, req.ModuloID, req.PermisoTipoID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Permiso removido exitosamente del grupo"})
+}
+
+// respond devuelve el grupo con sus roles y el total de miembros
+func (h *GrupoHandler) respond(c *gin.Context, status, id int) {
+	grupo, err := h.repo.WithContext(c.Request.Context()).GetByID(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	roles, err := h.repo.WithContext(c.Request.Context()).GetRoles(id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	total, err := h.repo.WithContext(c.Request.Context()).CountMembers(id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(status, models.GrupoResponse{
+		ID:                 grupo.ID,
+		Nombre:             grupo.Nombre,
+		Descripcion:        grupo.Descripcion,
+		Roles:              roles,
+		TotalMiembros:      total,
+		FechaCreacion:      grupo.FechaCreacion,
+		FechaActualizacion: grupo.FechaActualizacion,
+	})
+}
@@ -25,17 +25,21 @@ func (h *ModuleHandler) Create(c *gin.Context) {
 	}
 
 	module := &models.Module{
-		Nombre:      req.Nombre,
-		Descripcion: req.Descripcion,
+		Codigo:        req.Codigo,
+		Nombre:        req.Nombre,
+		Descripcion:   req.Descripcion,
+		IdModuloPadre: req.IdModuloPadre,
 	}
 
-	if err := h.repo.Create(module); err != nil {
+	if err := h.repo.WithContext(c.Request.Context()).Create(module); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
 	c.JSON(http.StatusCreated, models.ModuleResponse{
 		ID:                 module.ID,
+		IdModuloPadre:      module.IdModuloPadre,
+		Codigo:             module.Codigo,
 		Nombre:             module.Nombre,
 		Descripcion:        module.Descripcion,
 		FechaCreacion:      module.FechaCreacion,
@@ -43,8 +47,19 @@ func (h *ModuleHandler) Create(c *gin.Context) {
 	})
 }
 
+// GetAll lista los módulos activos; con ?tree=true los devuelve anidados
 func (h *ModuleHandler) GetAll(c *gin.Context) {
-	modules, err := h.repo.GetAll()
+	if c.Query("tree") == "true" {
+		tree, err := h.repo.WithContext(c.Request.Context()).GetTree()
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, tree)
+		return
+	}
+
+	modules, err := h.repo.WithContext(c.Request.Context()).GetAll()
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -54,6 +69,8 @@ func (h *ModuleHandler) GetAll(c *gin.Context) {
 	for i, module := range modules {
 		response[i] = models.ModuleResponse{
 			ID:                 module.ID,
+			IdModuloPadre:      module.IdModuloPadre,
+			Codigo:             module.Codigo,
 			Nombre:             module.Nombre,
 			Descripcion:        module.Descripcion,
 			FechaCreacion:      module.FechaCreacion,
@@ -64,14 +81,42 @@ func (h *ModuleHandler) GetAll(c *gin.Context) {
 	c.JSON(http.StatusOK, response)
 }
 
-func (h *ModuleHandler) GetModuleWithPermissions(c *gin.Context) {
+func (h *ModuleHandler) SetParent(c *gin.Context) {
 	id, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
 		return
 	}
 
-	moduleWithPermissions, err := h.repo.GetModuleWithPermissions(id)
+	var req models.SetModuleParentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.repo.WithContext(c.Request.Context()).SetParent(id, req.IdModuloPadre); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Módulo padre actualizado exitosamente",
+	})
+}
+
+// GetModuleWithPermissions acepta en la ruta el id o el código del módulo
+func (h *ModuleHandler) GetModuleWithPermissions(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		module, err := h.repo.WithContext(c.Request.Context()).GetByCodigo(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "módulo no encontrado"})
+			return
+		}
+		id = module.ID
+	}
+
+	moduleWithPermissions, err := h.repo.WithContext(c.Request.Context()).GetModuleWithPermissions(id)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -87,12 +132,19 @@ func (h *ModuleHandler) AssignPermissions(c *gin.Context) {
 		return
 	}
 
-	if err := h.repo.AssignPermissions(req.ModuloID, req.PermisoTipoIDs); err != nil {
+	moduloID, err := h.repo.WithContext(c.Request.Context()).ResolveID(req.ModuloID, req.ModuloCodigo)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	req.ModuloID = moduloID
+
+	if err := h.repo.WithContext(c.Request.Context()).AssignPermissions(req.ModuloID, req.PermisoTipoIDs); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	moduleWithPermissions, err := h.repo.GetModuleWithPermissions(req.ModuloID)
+	moduleWithPermissions, err := h.repo.WithContext(c.Request.Context()).GetModuleWithPermissions(req.ModuloID)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -103,8 +155,9 @@ func (h *ModuleHandler) AssignPermissions(c *gin.Context) {
 
 func (h *ModuleHandler) RemovePermission(c *gin.Context) {
 	var req struct {
-		ModuloID      int `json:"modulo_id" binding:"required"`
-		PermisoTipoID int `json:"permiso_tipo_id" binding:"required"`
+		ModuloID      int    `json:"modulo_id" binding:"required_without=ModuloCodigo"`
+		ModuloCodigo  string `json:"modulo_codigo"`
+		PermisoTipoID int    `json:"permiso_tipo_id" binding:"required"`
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -112,7 +165,14 @@ func (h *ModuleHandler) RemovePermission(c *gin.Context) {
 		return
 	}
 
-	if err := h.repo.RemovePermission(req.ModuloID, req.PermisoTipoID); err != nil {
+	moduloID, err := h.repo.WithContext(c.Request.Context()).ResolveID(req.ModuloID, req.ModuloCodigo)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	req.ModuloID = moduloID
+
+	if err := h.repo.WithContext(c.Request.Context()).RemovePermission(req.ModuloID, req.PermisoTipoID); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -129,7 +189,7 @@ func (h *ModuleHandler) Delete(c *gin.Context) {
 		return
 	}
 
-	if err := h.repo.Delete(id); err != nil {
+	if err := h.repo.WithContext(c.Request.Context()).Delete(id); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -145,12 +205,12 @@ func (h *ModuleHandler) Restore(c *gin.Context) {
 		return
 	}
 
-	if err := h.repo.Restore(id); err != nil {
+	if err := h.repo.WithContext(c.Request.Context()).Restore(id); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	moduleWithPermissions, err := h.repo.GetModuleWithPermissions(id)
+	moduleWithPermissions, err := h.repo.WithContext(c.Request.Context()).GetModuleWithPermissions(id)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -161,8 +221,27 @@ func (h *ModuleHandler) Restore(c *gin.Context) {
 		"module":  moduleWithPermissions,
 	})
 }
+
+// PreviewRestore muestra qué submódulos y permisos volvería a activar la
+// restauración del módulo, sin aplicarla
+func (h *ModuleHandler) PreviewRestore(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+
+	preview, err := h.repo.WithContext(c.Request.Context()).PreviewRestore(id)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, preview)
+}
+
 func (h *ModuleHandler) GetDeletedModules(c *gin.Context) {
-	modules, err := h.repo.GetDeletedModules()
+	modules, err := h.repo.WithContext(c.Request.Context()).GetDeletedModules()
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -172,6 +251,8 @@ func (h *ModuleHandler) GetDeletedModules(c *gin.Context) {
 	for i, module := range modules {
 		response[i] = models.ModuleResponse{
 			ID:                 module.ID,
+			IdModuloPadre:      module.IdModuloPadre,
+			Codigo:             module.Codigo,
 			Nombre:             module.Nombre,
 			Descripcion:        module.Descripcion,
 			FechaCreacion:      module.FechaCreacion,
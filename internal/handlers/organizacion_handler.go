@@ -0,0 +1,183 @@
+package handlers
+
+import (
+	"auth-service/internal/models"
+	"auth-service/internal/repository"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CabeceraOrganizacion indica la organización de la petición por su código o,
+// si ninguna tiene ese código, por su ID
+const CabeceraOrganizacion = "X-Organizacion"
+
+// ClaveOrganizacionToken es la clave del contexto de gin en la que la capa de
+// autenticación deja el ID de organización del token validado
+const ClaveOrganizacionToken = "id_organizacion"
+
+type OrganizacionHandler struct {
+	repo *repository.OrganizacionRepository
+}
+
+func NewOrganizacionHandler(repo *repository.OrganizacionRepository) *OrganizacionHandler {
+	return &OrganizacionHandler{repo: repo}
+}
+
+// organizacionToken devuelve la organización que la capa de autenticación
+// dejó en el contexto; ok es false si la petición no trae token
+func organizacionToken(c *gin.Context) (id int, ok bool, err error) {
+	valor, exists := c.Get(ClaveOrganizacionToken)
+	if !exists {
+		return 0, false, nil
+	}
+	id, ok = valor.(int)
+	if !ok {
+		return 0, false, fmt.Errorf("organización del token inválida")
+	}
+	return id, true, nil
+}
+
+// ResolverOrganizacion determina la organización de la petición a partir del
+// token y limita a ella las consultas de los repositorios. La cabecera solo
+// puede indicar la misma organización del token. Sin token, las consultas usan
+// la organización por defecto y las peticiones que modifican datos se rechazan.
+func (h *OrganizacionHandler) ResolverOrganizacion(c *gin.Context) {
+	organizacionID, deToken, err := organizacionToken(c)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+	if !deToken {
+		if c.GetHeader(CabeceraOrganizacion) != "" {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "la cabecera de organización requiere un token"})
+			return
+		}
+		if !soloLectura(c.Request.Method) {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "se requiere un token para modificar datos"})
+			return
+		}
+		organizacionID = models.OrganizacionPorDefecto
+	}
+
+	var organizacion *models.Organizacion
+	if valor := c.GetHeader(CabeceraOrganizacion); valor != "" {
+		organizacion, err = h.repo.GetByCodigo(valor)
+		if id, convErr := strconv.Atoi(valor); err != nil && convErr == nil {
+			organizacion, err = h.repo.GetByID(id)
+		}
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "organización no encontrada"})
+			return
+		}
+		if organizacion.ID != organizacionID {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "la organización indicada no corresponde a la del token"})
+			return
+		}
+	} else {
+		organizacion, err = h.repo.GetByID(organizacionID)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "organización no encontrada"})
+			return
+		}
+	}
+
+	if !organizacion.Activa {
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "la organización está inactiva"})
+		return
+	}
+
+	c.Request = c.Request.WithContext(repository.ConOrganizacion(c.Request.Context(), organizacion.ID))
+	c.Next()
+}
+
+// SoloPlataforma limita la ruta a las peticiones cuyo token pertenece a la
+// organización por defecto, que administra la plataforma y sus catálogos
+// globales. La cabecera de organización no cuenta: sin token se rechaza.
+func (h *OrganizacionHandler) SoloPlataforma(c *gin.Context) {
+	id, ok, err := organizacionToken(c)
+	if err != nil || !ok {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "se requiere un token de la organización de la plataforma"})
+		return
+	}
+	if id != models.OrganizacionPorDefecto {
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "solo la organización de la plataforma puede realizar esta operación"})
+		return
+	}
+	c.Next()
+}
+
+// soloLectura indica si el método HTTP no modifica datos
+func soloLectura(metodo string) bool {
+	return metodo == http.MethodGet || metodo == http.MethodHead || metodo == http.MethodOptions
+}
+
+func (h *OrganizacionHandler) Create(c *gin.Context) {
+	var req models.CreateOrganizacionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	organizacion := &models.Organizacion{
+		Codigo: req.Codigo,
+		Nombre: req.Nombre,
+	}
+
+	if err := h.repo.Create(organizacion); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, organizacion)
+}
+
+func (h *OrganizacionHandler) GetAll(c *gin.Context) {
+	organizaciones, err := h.repo.GetAll()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, organizaciones)
+}
+
+func (h *OrganizacionHandler) GetByID(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+
+	organizacion, err := h.repo.GetByID(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "organización no encontrada"})
+		return
+	}
+
+	c.JSON(http.StatusOK, organizacion)
+}
+
+func (h *OrganizacionHandler) Update(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+
+	var req models.UpdateOrganizacionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	organizacion, err := h.repo.Update(id, req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, organizacion)
+}
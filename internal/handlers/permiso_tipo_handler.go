@@ -3,6 +3,7 @@ package handlers
 import (
 	"auth-service/internal/models"
 	"auth-service/internal/repository"
+	"errors"
 	"net/http"
 	"strconv"
 
@@ -68,3 +69,58 @@ func (h *PermisoTipoHandler) GetByID(c *gin.Context) {
 
 	c.JSON(http.StatusOK, permisoTipo.ToResponse())
 }
+
+func (h *PermisoTipoHandler) Update(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+
+	var req models.UpdatePermisoTipoRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	permisoTipo, err := h.repo.GetByID(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	permisoTipo.Nombre = req.Nombre
+	permisoTipo.Descripcion = req.Descripcion
+
+	if err := h.repo.Update(permisoTipo); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, permisoTipo.ToResponse())
+}
+
+// Retire retira un tipo de permiso. Con ?cascade=true también retira las
+// asignaciones que lo usan; sin él responde 409 si el tipo está en uso.
+func (h *PermisoTipoHandler) Retire(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+
+	if err := h.repo.Delete(id, c.Query("cascade") == "true"); err != nil {
+		var enUso *repository.PermisoTipoEnUsoError
+		if errors.As(err, &enUso) {
+			c.JSON(http.StatusConflict, gin.H{
+				"error": err.Error(),
+				"uso":   enUso.Uso,
+			})
+			return
+		}
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Tipo de permiso retirado exitosamente"})
+}
@@ -0,0 +1,116 @@
+package handlers
+
+import (
+	"auth-service/internal/models"
+	"auth-service/internal/repository"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type PlantillaRolHandler struct {
+	repo *repository.PlantillaRolRepository
+}
+
+func NewPlantillaRolHandler(repo *repository.PlantillaRolRepository) *PlantillaRolHandler {
+	return &PlantillaRolHandler{repo: repo}
+}
+
+func (h *PlantillaRolHandler) Create(c *gin.Context) {
+	var req models.CreatePlantillaRolRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	plantilla := &models.PlantillaRol{
+		Nombre:      req.Nombre,
+		Descripcion: req.Descripcion,
+	}
+	for _, p := range req.Permisos {
+		plantilla.Permisos = append(plantilla.Permisos, p.ToPermiso())
+	}
+
+	if err := h.repo.WithContext(c.Request.Context()).Create(plantilla); err != nil {
+		if respondPermisosNoHabilitados(c, err) {
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	created, err := h.repo.WithContext(c.Request.Context()).GetByID(plantilla.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, created)
+}
+
+func (h *PlantillaRolHandler) GetAll(c *gin.Context) {
+	plantillas, err := h.repo.WithContext(c.Request.Context()).GetAll()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, plantillas)
+}
+
+func (h *PlantillaRolHandler) GetByID(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+
+	plantilla, err := h.repo.WithContext(c.Request.Context()).GetByID(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, plantilla)
+}
+
+func (h *PlantillaRolHandler) Delete(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+
+	if err := h.repo.WithContext(c.Request.Context()).Delete(id); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Plantilla eliminada exitosamente"})
+}
+
+func (h *PlantillaRolHandler) Instantiate(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+
+	var req models.InstantiatePlantillaRolRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	role, err := h.repo.WithContext(c.Request.Context()).Instantiate(id, req)
+	if err != nil {
+		if respondPermisosNoHabilitados(c, err) {
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, roleResponse(role))
+}
@@ -0,0 +1,51 @@
+package handlers
+
+import (
+	"auth-service/internal/repository"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type PurgaHandler struct {
+	repo          *repository.PurgaRepository
+	retencionDias int
+}
+
+func NewPurgaHandler(repo *repository.PurgaRepository, retencionDias int) *PurgaHandler {
+	return &PurgaHandler{repo: repo, retencionDias: retencionDias}
+}
+
+// Purge elimina definitivamente los registros de la organización de la
+// petición eliminados fuera del periodo de retención. Con ?dry_run=true solo informa lo que se eliminaría y con
+// ?retencion_dias= se usa otro periodo en lugar del configurado.
+func (h *PurgaHandler) Purge(c *gin.Context) {
+	dryRun := false
+	if valor := c.Query("dry_run"); valor != "" {
+		var err error
+		dryRun, err = strconv.ParseBool(valor)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "dry_run inválido"})
+			return
+		}
+	}
+
+	retencionDias := h.retencionDias
+	if valor := c.Query("retencion_dias"); valor != "" {
+		dias, err := strconv.Atoi(valor)
+		if err != nil || dias < 1 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "retencion_dias debe ser un número entero mayor que cero"})
+			return
+		}
+		retencionDias = dias
+	}
+
+	reporte, err := h.repo.WithContext(c.Request.Context()).Purge(retencionDias, dryRun)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, reporte)
+}
@@ -0,0 +1,135 @@
+package handlers
+
+import (
+	"auth-service/internal/models"
+	"auth-service/internal/repository"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type RecursoPermisoHandler struct {
+	repo *repository.RecursoPermisoRepository
+}
+
+func NewRecursoPermisoHandler(repo *repository.RecursoPermisoRepository) *RecursoPermisoHandler {
+	return &RecursoPermisoHandler{repo: repo}
+}
+
+func (h *RecursoPermisoHandler) Create(c *gin.Context) {
+	var req models.CreateRecursoPermisoRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	grant := &models.RecursoPermiso{
+		IdModulo:      req.ModuloID,
+		TipoRecurso:   req.TipoRecurso,
+		IdRecurso:     req.IdRecurso,
+		IdPermisoTipo: req.PermisoTipoID,
+		IdUsuario:     req.IdUsuario,
+		IdRol:         req.IdRol,
+	}
+
+	if err := h.repo.WithContext(c.Request.Context()).Create(grant); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	created, err := h.repo.WithContext(c.Request.Context()).GetByID(grant.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, created)
+}
+
+func (h *RecursoPermisoHandler) GetAll(c *gin.Context) {
+	filter := models.RecursoPermisoFilter{
+		TipoRecurso: c.Query("tipo_recurso"),
+		IdRecurso:   c.Query("id_recurso"),
+	}
+	for param, target := range map[string]*int{
+		"id_modulo":  &filter.IdModulo,
+		"id_usuario": &filter.IdUsuario,
+		"id_rol":     &filter.IdRol,
+	} {
+		if value := c.Query(param); value != "" {
+			id, err := strconv.Atoi(value)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Parámetro inválido: " + param})
+				return
+			}
+			*target = id
+		}
+	}
+
+	grants, err := h.repo.WithContext(c.Request.Context()).GetAll(filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, grants)
+}
+
+func (h *RecursoPermisoHandler) GetByID(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+
+	grant, err := h.repo.WithContext(c.Request.Context()).GetByID(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, grant)
+}
+
+func (h *RecursoPermisoHandler) Update(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+
+	var req models.UpdateRecursoPermisoRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.repo.WithContext(c.Request.Context()).UpdatePermisoTipo(id, req.PermisoTipoID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	grant, err := h.repo.WithContext(c.Request.Context()).GetByID(id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, grant)
+}
+
+func (h *RecursoPermisoHandler) Delete(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+
+	if err := h.repo.WithContext(c.Request.Context()).Delete(id); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Permiso de recurso eliminado exitosamente"})
+}
@@ -0,0 +1,115 @@
+package handlers
+
+import (
+	"auth-service/internal/models"
+	"auth-service/internal/repository"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type RegionalHandler struct {
+	repo *repository.RegionalRepository
+}
+
+func NewRegionalHandler(repo *repository.RegionalRepository) *RegionalHandler {
+	return &RegionalHandler{repo: repo}
+}
+
+func (h *RegionalHandler) Create(c *gin.Context) {
+	var req models.CreateRegionalRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	regional := &models.Regional{Nombre: req.Nombre}
+	if err := h.repo.WithContext(c.Request.Context()).Create(regional); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, regional)
+}
+
+func (h *RegionalHandler) GetAll(c *gin.Context) {
+	regionales, err := h.repo.WithContext(c.Request.Context()).GetAll()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, regionales)
+}
+
+func (h *RegionalHandler) GetByID(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+
+	regional, err := h.repo.WithContext(c.Request.Context()).GetByID(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, regional)
+}
+
+func (h *RegionalHandler) Update(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+
+	var req models.CreateRegionalRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	regional, err := h.repo.WithContext(c.Request.Context()).Update(id, req.Nombre)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, regional)
+}
+
+// Delete elimina una regional; si aún tiene sedes o usuarios responde 409
+func (h *RegionalHandler) Delete(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+
+	if err := h.repo.WithContext(c.Request.Context()).Delete(id); err != nil {
+		respondCatalogoEnUso(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Regional eliminada exitosamente"})
+}
+
+// respondCatalogoEnUso responde 409 si la sede o regional aún está en uso y
+// 400 ante cualquier otro error
+func respondCatalogoEnUso(c *gin.Context, err error) {
+	var enUso *repository.CatalogoEnUsoError
+	if errors.As(err, &enUso) {
+		c.JSON(http.StatusConflict, gin.H{
+			"error":        err.Error(),
+			"usuarios":     enUso.Usuarios,
+			"sedes":        enUso.Sedes,
+			"asignaciones": enUso.Asignaciones,
+		})
+		return
+	}
+	c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+}
@@ -3,8 +3,10 @@ package handlers
 import (
 	"auth-service/internal/models"
 	"auth-service/internal/repository"
+	"errors"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
@@ -12,12 +14,14 @@ import (
 type RoleHandler struct {
 	repo                 *repository.RoleRepository
 	rolModuloPermisoRepo *repository.RolModuloPermisoRepository
+	moduleRepo           *repository.ModuleRepository
 }
 
-func NewRoleHandler(repo *repository.RoleRepository, rmpRepo *repository.RolModuloPermisoRepository) *RoleHandler {
+func NewRoleHandler(repo *repository.RoleRepository, rmpRepo *repository.RolModuloPermisoRepository, moduleRepo *repository.ModuleRepository) *RoleHandler {
 	return &RoleHandler{
 		repo:                 repo,
 		rolModuloPermisoRepo: rmpRepo,
+		moduleRepo:           moduleRepo,
 	}
 }
 
@@ -33,39 +37,176 @@ func (h *RoleHandler) Create(c *gin.Context) {
 		Descripcion: req.Descripcion,
 	}
 
-	if err := h.repo.Create(role); err != nil {
+	if err := h.repo.WithContext(c.Request.Context()).Create(role); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusCreated, models.RoleResponse{
-		ID:                 role.ID,
-		Nombre:             role.Nombre,
-		FechaCreacion:      role.FechaCreacion,
-		FechaActualizacion: role.FechaActualizacion,
-	})
+	c.JSON(http.StatusCreated, roleResponse(role))
 }
 
 func (h *RoleHandler) GetAll(c *gin.Context) {
-	roles, err := h.repo.GetAll()
+	roles, err := h.repo.WithContext(c.Request.Context()).GetAll()
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
 	response := make([]models.RoleResponse, len(roles))
-	for i, role := range roles {
-		response[i] = models.RoleResponse{
-			ID:                 role.ID,
-			Nombre:             role.Nombre,
-			FechaCreacion:      role.FechaCreacion,
-			FechaActualizacion: role.FechaActualizacion,
+	for i := range roles {
+		response[i] = roleResponse(&roles[i])
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+func (h *RoleHandler) GetByID(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+
+	role, err := h.repo.WithContext(c.Request.Context()).GetByID(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "rol no encontrado"})
+		return
+	}
+
+	c.JSON(http.StatusOK, roleResponse(role))
+}
+
+func (h *RoleHandler) Update(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+
+	var req models.CreateRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	role, err := h.repo.WithContext(c.Request.Context()).GetByID(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "rol no encontrado"})
+		return
+	}
+
+	role.Nombre = req.Nombre
+	role.Descripcion = req.Descripcion
+
+	if err := h.repo.WithContext(c.Request.Context()).Update(role); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, roleResponse(role))
+}
+
+// Delete elimina un rol. Si aún lo tienen usuarios o grupos responde 409,
+// salvo que ?reasignar_a indique el rol al que pasarlos.
+func (h *RoleHandler) Delete(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+
+	var reasignarA *int
+	if valor := c.Query("reasignar_a"); valor != "" {
+		destinoID, err := strconv.Atoi(valor)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "reasignar_a inválido"})
+			return
+		}
+		reasignarA = &destinoID
+	}
+
+	if err := h.repo.WithContext(c.Request.Context()).Delete(id, reasignarA); err != nil {
+		var enUso *repository.RolEnUsoError
+		if errors.As(err, &enUso) {
+			c.JSON(http.StatusConflict, gin.H{
+				"error":    err.Error(),
+				"usuarios": enUso.Usuarios,
+				"grupos":   enUso.Grupos,
+			})
+			return
 		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Rol eliminado exitosamente"})
+}
+
+func (h *RoleHandler) Restore(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+
+	if err := h.repo.WithContext(c.Request.Context()).Restore(id); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	role, err := h.repo.WithContext(c.Request.Context()).GetByID(id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Rol restaurado exitosamente",
+		"role":    roleResponse(role),
+	})
+}
+
+func (h *RoleHandler) GetDeletedRoles(c *gin.Context) {
+	roles, err := h.repo.WithContext(c.Request.Context()).GetDeletedRoles()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	response := make([]models.RoleResponse, len(roles))
+	for i := range roles {
+		response[i] = roleResponse(&roles[i])
 	}
 
 	c.JSON(http.StatusOK, response)
 }
 
+func (h *RoleHandler) Clone(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+
+	var req models.CreateRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	role := &models.Role{
+		Nombre:      req.Nombre,
+		Descripcion: req.Descripcion,
+	}
+
+	if err := h.repo.WithContext(c.Request.Context()).Clone(id, role); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, roleResponse(role))
+}
+
 func (h *RoleHandler) AssignModulePermission(c *gin.Context) {
 	var req models.AssignRolePermissionsRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -73,7 +214,19 @@ func (h *RoleHandler) AssignModulePermission(c *gin.Context) {
 		return
 	}
 
-	if err := h.repo.AssignModulePermission(req.RoleID, req.ModuloID, req.PermisoTipoID); err != nil {
+	if !req.TodosModulos {
+		moduloID, err := h.moduleRepo.WithContext(c.Request.Context()).ResolveID(req.ModuloID, req.ModuloCodigo)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		req.ModuloID = moduloID
+	}
+
+	if err := h.repo.WithContext(c.Request.Context()).AssignModulePermission(req); err != nil {
+		if respondPermisosNoHabilitados(c, err) {
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -91,7 +244,7 @@ func (h *RoleHandler) GetRolePermissions(c *gin.Context) {
 		return
 	}
 
-	permissions, err := h.repo.GetRolePermissions(id)
+	permissions, err := h.repo.WithContext(c.Request.Context()).GetRolePermissions(id)
 	if err != nil {
 		if err.Error() == "rol no encontrado: record not found" {
 			c.JSON(http.StatusNotFound, gin.H{"error": "Rol no encontrado"})
@@ -104,11 +257,43 @@ func (h *RoleHandler) GetRolePermissions(c *gin.Context) {
 	c.JSON(http.StatusOK, permissions)
 }
 
+// GetInconsistentGrants informa las asignaciones existentes cuyo módulo está
+// eliminado o no ofrece el permiso; RepairInconsistentGrants además las elimina
+func (h *RoleHandler) GetInconsistentGrants(c *gin.Context) {
+	inconsistentes, err := h.rolModuloPermisoRepo.WithContext(c.Request.Context()).GetInconsistentGrants()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.InconsistenciasResponse{
+		Total:        len(inconsistentes),
+		Asignaciones: inconsistentes,
+	})
+}
+
+func (h *RoleHandler) RepairInconsistentGrants(c *gin.Context) {
+	reparadas, err := h.rolModuloPermisoRepo.WithContext(c.Request.Context()).RepairInconsistentGrants(time.Now())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.InconsistenciasResponse{
+		Total:        len(reparadas),
+		Reparadas:    true,
+		Asignaciones: reparadas,
+	})
+}
+
 func (h *RoleHandler) RemoveModulePermission(c *gin.Context) {
 	var req struct {
-		RoleID        int `json:"role_id" binding:"required"`
-		ModuloID      int `json:"modulo_id" binding:"required"`
-		PermisoTipoID int `json:"permiso_tipo_id" binding:"required"`
+		RoleID        int    `json:"role_id" binding:"required"`
+		ModuloID      int    `json:"modulo_id" binding:"required_without_all=TodosModulos ModuloCodigo"`
+		ModuloCodigo  string `json:"modulo_codigo"`
+		PermisoTipoID int    `json:"permiso_tipo_id" binding:"required_without=TodosPermisos"`
+		TodosModulos  bool   `json:"todos_modulos"`
+		TodosPermisos bool   `json:"todos_permisos"`
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -116,7 +301,21 @@ func (h *RoleHandler) RemoveModulePermission(c *gin.Context) {
 		return
 	}
 
-	if err := h.repo.RemoveModulePermission(req.RoleID, req.ModuloID, req.PermisoTipoID); err != nil {
+	// Los comodines se identifican con un módulo o permiso nulo
+	var moduloID, permisoTipoID *int
+	if !req.TodosModulos {
+		id, err := h.moduleRepo.WithContext(c.Request.Context()).ResolveID(req.ModuloID, req.ModuloCodigo)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		moduloID = &id
+	}
+	if !req.TodosPermisos {
+		permisoTipoID = &req.PermisoTipoID
+	}
+
+	if err := h.repo.WithContext(c.Request.Context()).RemoveModulePermission(req.RoleID, moduloID, permisoTipoID); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -137,7 +336,7 @@ func (h *RoleHandler) RemoveModuleFromRole(c *gin.Context) {
 		return
 	}
 
-	if err := h.repo.RemoveModuleFromRole(req.RoleID, req.ModuloID); err != nil {
+	if err := h.repo.WithContext(c.Request.Context()).RemoveModuleFromRole(req.RoleID, req.ModuloID); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -146,3 +345,103 @@ func (h *RoleHandler) RemoveModuleFromRole(c *gin.Context) {
 		"message": "Módulo removido exitosamente del rol",
 	})
 }
+
+func (h *RoleHandler) AddParent(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+
+	var req models.AddRolPadreRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.repo.WithContext(c.Request.Context()).AddParent(id, req.IdRolPadre); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Rol padre asignado exitosamente",
+	})
+}
+
+func (h *RoleHandler) GetParents(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+
+	parents, err := h.repo.WithContext(c.Request.Context()).GetParents(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	response := make([]models.RoleResponse, len(parents))
+	for i, role := range parents {
+		response[i] = models.RoleResponse{
+			ID:                 role.ID,
+			Nombre:             role.Nombre,
+			Descripcion:        role.Descripcion,
+			FechaCreacion:      role.FechaCreacion,
+			FechaActualizacion: role.FechaActualizacion,
+		}
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+func (h *RoleHandler) RemoveParent(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+
+	parentID, err := strconv.Atoi(c.Param("parentId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID de rol padre inválido"})
+		return
+	}
+
+	if err := h.repo.WithContext(c.Request.Context()).RemoveParent(id, parentID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Rol padre removido exitosamente",
+	})
+}
+
+// respondPermisosNoHabilitados responde con los códigos que el módulo no
+// ofrece si el error es un *repository.PermisosNoHabilitadosError
+func respondPermisosNoHabilitados(c *gin.Context, err error) bool {
+	var noHabilitados *repository.PermisosNoHabilitadosError
+	if !errors.As(err, &noHabilitados) {
+		return false
+	}
+
+	c.JSON(http.StatusUnprocessableEntity, gin.H{
+		"error":     err.Error(),
+		"modulo_id": noHabilitados.IdModulo,
+		"codigos":   noHabilitados.Codigos,
+	})
+	return true
+}
+
+func roleResponse(role *models.Role) models.RoleResponse {
+	return models.RoleResponse{
+		ID:                 role.ID,
+		Nombre:             role.Nombre,
+		Descripcion:        role.Descripcion,
+		FechaCreacion:      role.FechaCreacion,
+		FechaActualizacion: role.FechaActualizacion,
+		FechaEliminacion:   role.FechaEliminacion,
+	}
+}
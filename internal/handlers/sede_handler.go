@@ -0,0 +1,112 @@
+package handlers
+
+import (
+	"auth-service/internal/models"
+	"auth-service/internal/repository"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type SedeHandler struct {
+	repo *repository.SedeRepository
+}
+
+func NewSedeHandler(repo *repository.SedeRepository) *SedeHandler {
+	return &SedeHandler{repo: repo}
+}
+
+func (h *SedeHandler) Create(c *gin.Context) {
+	var req models.CreateSedeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	sede := &models.Sede{
+		Nombre:     req.Nombre,
+		IdRegional: req.IdRegional,
+	}
+	if err := h.repo.WithContext(c.Request.Context()).Create(sede); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, sede)
+}
+
+// GetAll lista las sedes; con ?id_regional= solo las de esa regional
+func (h *SedeHandler) GetAll(c *gin.Context) {
+	regionalID := 0
+	if valor := c.Query("id_regional"); valor != "" {
+		id, err := strconv.Atoi(valor)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "id_regional inválido"})
+			return
+		}
+		regionalID = id
+	}
+
+	sedes, err := h.repo.WithContext(c.Request.Context()).GetAll(regionalID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, sedes)
+}
+
+func (h *SedeHandler) GetByID(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+
+	sede, err := h.repo.WithContext(c.Request.Context()).GetByID(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, sede)
+}
+
+func (h *SedeHandler) Update(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+
+	var req models.CreateSedeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	sede, err := h.repo.WithContext(c.Request.Context()).Update(id, req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, sede)
+}
+
+// Delete elimina una sede; si aún tiene usuarios responde 409
+func (h *SedeHandler) Delete(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+
+	if err := h.repo.WithContext(c.Request.Context()).Delete(id); err != nil {
+		respondCatalogoEnUso(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Sede eliminada exitosamente"})
+}
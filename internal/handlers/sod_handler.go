@@ -0,0 +1,83 @@
+package handlers
+
+import (
+	"auth-service/internal/models"
+	"auth-service/internal/repository"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type SoDHandler struct {
+	repo *repository.SoDRepository
+}
+
+func NewSoDHandler(repo *repository.SoDRepository) *SoDHandler {
+	return &SoDHandler{repo: repo}
+}
+
+func (h *SoDHandler) Create(c *gin.Context) {
+	var req models.CreateRestriccionSoDRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	restriccion := &models.RestriccionSoD{
+		Nombre:         req.Nombre,
+		Descripcion:    req.Descripcion,
+		Tipo:           req.Tipo,
+		IdRolA:         req.IdRolA,
+		IdRolB:         req.IdRolB,
+		IdModuloA:      req.IdModuloA,
+		IdPermisoTipoA: req.IdPermisoTipoA,
+		IdModuloB:      req.IdModuloB,
+		IdPermisoTipoB: req.IdPermisoTipoB,
+	}
+
+	if err := h.repo.WithContext(c.Request.Context()).Create(restriccion); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, restriccion)
+}
+
+func (h *SoDHandler) GetAll(c *gin.Context) {
+	restricciones, err := h.repo.WithContext(c.Request.Context()).GetAll()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, restricciones)
+}
+
+func (h *SoDHandler) Delete(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+
+	if err := h.repo.WithContext(c.Request.Context()).Delete(id); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Restricción eliminada exitosamente"})
+}
+
+func (h *SoDHandler) GetViolations(c *gin.Context) {
+	violaciones, err := h.repo.WithContext(c.Request.Context()).GetViolations()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"total":       len(violaciones),
+		"violaciones": violaciones,
+	})
+}
@@ -0,0 +1,91 @@
+package handlers
+
+import (
+	"auth-service/internal/models"
+	"auth-service/internal/repository"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type TipoDocumentoHandler struct {
+	repo *repository.TipoDocumentoRepository
+}
+
+func NewTipoDocumentoHandler(repo *repository.TipoDocumentoRepository) *TipoDocumentoHandler {
+	return &TipoDocumentoHandler{repo: repo}
+}
+
+func (h *TipoDocumentoHandler) Create(c *gin.Context) {
+	var req models.CreateTipoDocumentoRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	tipo := &models.TipoDocumento{
+		Codigo:             req.Codigo,
+		Nombre:             req.Nombre,
+		Patron:             req.Patron,
+		LongitudMinima:     req.LongitudMinima,
+		LongitudMaxima:     req.LongitudMaxima,
+		DigitoVerificacion: req.DigitoVerificacion,
+	}
+	if err := h.repo.Create(tipo); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, tipo)
+}
+
+// GetAll lista los tipos de documento; ?activos=true devuelve solo los que se
+// pueden asignar a usuarios
+func (h *TipoDocumentoHandler) GetAll(c *gin.Context) {
+	tipos, err := h.repo.GetAll(c.Query("activos") == "true")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, tipos)
+}
+
+func (h *TipoDocumentoHandler) GetByID(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+
+	tipo, err := h.repo.GetByID(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, tipo)
+}
+
+func (h *TipoDocumentoHandler) Update(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+
+	var req models.UpdateTipoDocumentoRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	tipo, err := h.repo.Update(id, req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, tipo)
+}
@@ -30,14 +30,14 @@ func (h *UserHandler) Create(c *gin.Context) {
 	}
 
 	// Verificar si el rol existe
-	_, err := h.roleRepo.GetByID(req.IdRol)
+	_, err := h.roleRepo.WithContext(c.Request.Context()).GetByID(req.IdRol)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "El rol especificado no existe"})
 		return
 	}
 
 	// Verificar si ya existe un usuario con el mismo correo
-	exists, err := h.repo.ExistsByEmail(req.Correo)
+	exists, err := h.repo.WithContext(c.Request.Context()).ExistsByEmail(req.Correo)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -48,7 +48,7 @@ func (h *UserHandler) Create(c *gin.Context) {
 	}
 
 	// Verificar si ya existe un usuario con el mismo documento
-	exists, err = h.repo.ExistsByDocumento(req.TipoDocumento, req.NumeroDocumento)
+	exists, err = h.repo.WithContext(c.Request.Context()).ExistsByDocumento(req.TipoDocumento, req.NumeroDocumento)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -63,45 +63,52 @@ func (h *UserHandler) Create(c *gin.Context) {
 		Apellidos:       req.Apellidos,
 		TipoDocumento:   req.TipoDocumento,
 		NumeroDocumento: req.NumeroDocumento,
-		Sede:            req.Sede,
+		IdSede:          &req.IdSede,
 		IdRol:           req.IdRol,
-		Regional:        req.Regional,
 		Correo:          req.Correo,
 		Telefono:        req.Telefono,
 		Contraseña:      req.Contraseña,
+		RolValidoDesde:  req.RolValidoDesde,
+		RolValidoHasta:  req.RolValidoHasta,
+		Estado:          req.Estado,
+	}
+	if user.Estado == "" {
+		user.Estado = models.EstadoActivo
 	}
 
-	if err := h.repo.Create(user); err != nil {
+	// Un usuario eliminado con el mismo documento se reactiva
+	reactivado, err := h.repo.WithContext(c.Request.Context()).Create(user)
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
 	// Obtener el usuario con su rol para la respuesta
-	createdUser, err := h.repo.GetByID(user.ID)
+	createdUser, err := h.repo.WithContext(c.Request.Context()).GetByID(user.ID)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusCreated, models.UserResponse{
-		ID:                 createdUser.ID,
-		Nombre:             createdUser.Nombre,
-		Apellidos:          createdUser.Apellidos,
-		TipoDocumento:      createdUser.TipoDocumento,
-		NumeroDocumento:    createdUser.NumeroDocumento,
-		Sede:               createdUser.Sede,
-		IdRol:              createdUser.IdRol,
-		Role:               createdUser.Role,
-		Regional:           createdUser.Regional,
-		Correo:             createdUser.Correo,
-		Telefono:           createdUser.Telefono,
-		FechaCreacion:      createdUser.FechaCreacion,
-		FechaActualizacion: createdUser.FechaActualizacion,
-	})
+	status := http.StatusCreated
+	if reactivado {
+		status = http.StatusOK
+	}
+	c.JSON(status, newUserResponse(createdUser))
 }
 
+// GetAll lista los usuarios; ?estado= filtra por estado de la cuenta
 func (h *UserHandler) GetAll(c *gin.Context) {
-	users, err := h.repo.GetAll()
+	estado := c.Query("estado")
+	switch estado {
+	case "", models.EstadoPendiente, models.EstadoActivo, models.EstadoSuspendido,
+		models.EstadoBloqueado, models.EstadoRetirado:
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "estado inválido"})
+		return
+	}
+
+	users, err := h.repo.WithContext(c.Request.Context()).GetAll(estado)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -109,21 +116,7 @@ func (h *UserHandler) GetAll(c *gin.Context) {
 
 	response := make([]models.UserResponse, len(users))
 	for i, user := range users {
-		response[i] = models.UserResponse{
-			ID:                 user.ID,
-			Nombre:             user.Nombre,
-			Apellidos:          user.Apellidos,
-			TipoDocumento:      user.TipoDocumento,
-			NumeroDocumento:    user.NumeroDocumento,
-			Sede:               user.Sede,
-			IdRol:              user.IdRol,
-			Role:               user.Role,
-			Regional:           user.Regional,
-			Correo:             user.Correo,
-			Telefono:           user.Telefono,
-			FechaCreacion:      user.FechaCreacion,
-			FechaActualizacion: user.FechaActualizacion,
-		}
+		response[i] = newUserResponse(&user)
 	}
 
 	c.JSON(http.StatusOK, response)
@@ -135,27 +128,13 @@ func (h *UserHandler) GetByID(c *gin.Context) {
 		return
 	}
 
-	user, err := h.repo.GetByID(id)
+	user, err := h.repo.WithContext(c.Request.Context()).GetByID(id)
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, models.UserResponse{
-		ID:                 user.ID,
-		Nombre:             user.Nombre,
-		Apellidos:          user.Apellidos,
-		TipoDocumento:      user.TipoDocumento,
-		NumeroDocumento:    user.NumeroDocumento,
-		Sede:               user.Sede,
-		IdRol:              user.IdRol,
-		Role:               user.Role,
-		Regional:           user.Regional,
-		Correo:             user.Correo,
-		Telefono:           user.Telefono,
-		FechaCreacion:      user.FechaCreacion,
-		FechaActualizacion: user.FechaActualizacion,
-	})
+	c.JSON(http.StatusOK, newUserResponse(user))
 }
 
 func (h *UserHandler) Update(c *gin.Context) {
@@ -172,7 +151,7 @@ func (h *UserHandler) Update(c *gin.Context) {
 	}
 
 	// Obtener usuario existente
-	user, err := h.repo.GetByID(id)
+	user, err := h.repo.WithContext(c.Request.Context()).GetByID(id)
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
 		return
@@ -180,7 +159,7 @@ func (h *UserHandler) Update(c *gin.Context) {
 
 	// Verificar si el rol existe cuando se intenta cambiar
 	if req.IdRol != user.IdRol {
-		_, err := h.roleRepo.GetByID(req.IdRol)
+		_, err := h.roleRepo.WithContext(c.Request.Context()).GetByID(req.IdRol)
 		if err != nil {
 			c.JSON(http.StatusBadRequest, gin.H{"error": "El rol especificado no existe"})
 			return
@@ -192,40 +171,27 @@ func (h *UserHandler) Update(c *gin.Context) {
 	user.Apellidos = req.Apellidos
 	user.TipoDocumento = req.TipoDocumento
 	user.NumeroDocumento = req.NumeroDocumento
-	user.Sede = req.Sede
-	user.Regional = req.Regional
+	user.IdSede = &req.IdSede
 	user.Correo = req.Correo
 	user.Telefono = req.Telefono
 	user.IdRol = req.IdRol
+	user.RolValidoDesde = req.RolValidoDesde
+	user.RolValidoHasta = req.RolValidoHasta
 
 	// Actualizar usuario
-	if err := h.repo.Update(user); err != nil {
+	if err := h.repo.WithContext(c.Request.Context()).Update(user); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
 	// Obtener usuario actualizado con información del rol
-	updatedUser, err := h.repo.GetByID(id)
+	updatedUser, err := h.repo.WithContext(c.Request.Context()).GetByID(id)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, models.UserResponse{
-		ID:                 updatedUser.ID,
-		Nombre:             updatedUser.Nombre,
-		Apellidos:          updatedUser.Apellidos,
-		TipoDocumento:      updatedUser.TipoDocumento,
-		NumeroDocumento:    updatedUser.NumeroDocumento,
-		Sede:               updatedUser.Sede,
-		IdRol:              updatedUser.IdRol,
-		Role:               updatedUser.Role,
-		Regional:           updatedUser.Regional,
-		Correo:             updatedUser.Correo,
-		Telefono:           updatedUser.Telefono,
-		FechaCreacion:      updatedUser.FechaCreacion,
-		FechaActualizacion: updatedUser.FechaActualizacion,
-	})
+	c.JSON(http.StatusOK, newUserResponse(updatedUser))
 }
 
 func (h *UserHandler) Delete(c *gin.Context) {
@@ -235,16 +201,98 @@ func (h *UserHandler) Delete(c *gin.Context) {
 		return
 	}
 
-	if err := h.repo.Delete(id); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	if err := h.repo.WithContext(c.Request.Context()).Delete(id); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{"message": "Usuario eliminado exitosamente"})
 }
 
+func (h *UserHandler) ChangeEstado(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+
+	var req models.CambiarEstadoUsuarioRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.repo.WithContext(c.Request.Context()).ChangeEstado(id, req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, err := h.repo.WithContext(c.Request.Context()).GetByID(id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, newUserResponse(user))
+}
+
+func (h *UserHandler) GetEstadoHistorial(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+
+	historial, err := h.repo.WithContext(c.Request.Context()).GetEstadoHistorial(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, historial)
+}
+
+func (h *UserHandler) Restore(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+
+	if err := h.repo.WithContext(c.Request.Context()).Restore(id); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, err := h.repo.WithContext(c.Request.Context()).GetByID(id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Usuario restaurado exitosamente",
+		"user":    newUserResponse(user),
+	})
+}
+
+func (h *UserHandler) GetDeletedUsers(c *gin.Context) {
+	users, err := h.repo.WithContext(c.Request.Context()).GetDeletedUsers()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	response := make([]models.UserResponse, len(users))
+	for i := range users {
+		response[i] = newUserResponse(&users[i])
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
 func (h *UserHandler) GetAllUsersWithPermissions(c *gin.Context) {
-	response, err := h.repo.GetAllUsersWithPermissions()
+	response, err := h.repo.WithContext(c.Request.Context()).GetAllUsersWithPermissions()
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -260,7 +308,7 @@ func (h *UserHandler) GetUserPermissions(c *gin.Context) {
 		return
 	}
 
-	permissions, err := h.repo.GetUserPermissions(userID)
+	permissions, err := h.repo.WithContext(c.Request.Context()).GetUserPermissions(userID)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -286,12 +334,17 @@ func (h *UserHandler) ChangePassword(c *gin.Context) {
 		return
 	}
 
-	user, err := h.repo.GetByID(id)
+	user, err := h.repo.WithContext(c.Request.Context()).GetByID(id)
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
 		return
 	}
 
+	if !user.Activo() {
+		c.JSON(http.StatusForbidden, gin.H{"error": "La cuenta del usuario está " + user.Estado})
+		return
+	}
+
 	// Verificar contraseña actual
 	if !user.ValidatePassword(req.CurrentPassword) {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "Contraseña actual incorrecta"})
@@ -306,10 +359,34 @@ func (h *UserHandler) ChangePassword(c *gin.Context) {
 	}
 
 	// Actualizar contraseña
-	if err := h.repo.UpdatePassword(id, string(hashedPassword)); err != nil {
+	if err := h.repo.WithContext(c.Request.Context()).UpdatePassword(id, string(hashedPassword)); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{"message": "Contraseña actualizada exitosamente"})
 }
+
+func newUserResponse(user *models.User) models.UserResponse {
+	return models.UserResponse{
+		ID:                 user.ID,
+		Nombre:             user.Nombre,
+		Apellidos:          user.Apellidos,
+		TipoDocumento:      user.TipoDocumento,
+		NumeroDocumento:    user.NumeroDocumento,
+		IdSede:             user.IdSede,
+		Sede:               user.Sede,
+		IdRol:              user.IdRol,
+		Role:               user.Role,
+		RolValidoDesde:     user.RolValidoDesde,
+		RolValidoHasta:     user.RolValidoHasta,
+		Estado:             user.Estado,
+		IdRegional:         user.IdRegional,
+		Regional:           user.Regional,
+		Correo:             user.Correo,
+		Telefono:           user.Telefono,
+		FechaCreacion:      user.FechaCreacion,
+		FechaActualizacion: user.FechaActualizacion,
+		FechaEliminacion:   user.FechaEliminacion,
+	}
+}
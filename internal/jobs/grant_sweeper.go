@@ -0,0 +1,45 @@
+package jobs
+
+import (
+	"auth-service/internal/repository"
+	"log"
+	"time"
+)
+
+// GrantSweeper elimina periódicamente las asignaciones de permisos vencidas
+// y expira las elevaciones temporales cuyo plazo terminó
+type GrantSweeper struct {
+	repo          *repository.RolModuloPermisoRepository
+	elevacionRepo *repository.ElevacionRepository
+	interval      time.Duration
+}
+
+func NewGrantSweeper(repo *repository.RolModuloPermisoRepository, elevacionRepo *repository.ElevacionRepository, interval time.Duration) *GrantSweeper {
+	return &GrantSweeper{repo: repo, elevacionRepo: elevacionRepo, interval: interval}
+}
+
+// Start ejecuta una limpieza inmediata y luego una por cada intervalo
+func (s *GrantSweeper) Start() {
+	go func() {
+		s.sweep()
+		ticker := time.NewTicker(s.interval)
+		defer ticker.Stop()
+		for range ticker.C {
+			s.sweep()
+		}
+	}()
+}
+
+func (s *GrantSweeper) sweep() {
+	if count, err := s.repo.ExpireGrants(time.Now()); err != nil {
+		log.Printf("Error al eliminar permisos vencidos: %v", err)
+	} else if count > 0 {
+		log.Printf("Se eliminaron %d permisos vencidos", count)
+	}
+
+	if count, err := s.elevacionRepo.ExpireElevations(time.Now()); err != nil {
+		log.Printf("Error al expirar elevaciones: %v", err)
+	} else if count > 0 {
+		log.Printf("Se expiraron %d elevaciones temporales", count)
+	}
+}
@@ -0,0 +1,46 @@
+package jobs
+
+import (
+	"auth-service/internal/repository"
+	"log"
+	"time"
+)
+
+// RetentionPurger purga periódicamente los registros eliminados lógicamente
+// que superaron el periodo de retención
+type RetentionPurger struct {
+	repo          *repository.PurgaRepository
+	retencionDias int
+	interval      time.Duration
+}
+
+func NewRetentionPurger(repo *repository.PurgaRepository, retencionDias int, interval time.Duration) *RetentionPurger {
+	return &RetentionPurger{repo: repo, retencionDias: retencionDias, interval: interval}
+}
+
+// Start ejecuta una purga inmediata y luego una por cada intervalo
+func (p *RetentionPurger) Start() {
+	go func() {
+		p.purge()
+		ticker := time.NewTicker(p.interval)
+		defer ticker.Stop()
+		for range ticker.C {
+			p.purge()
+		}
+	}()
+}
+
+func (p *RetentionPurger) purge() {
+	reporte, err := p.repo.Purge(p.retencionDias, false)
+	if err != nil {
+		log.Printf("Error al purgar registros eliminados: %v", err)
+		return
+	}
+	if reporte.RolModuloPermisos > 0 || reporte.ModuloPermisos > 0 || len(reporte.Modulos) > 0 {
+		log.Printf("Purga de eliminados anteriores a %s: %d asignaciones de rol, %d permisos de módulo, módulos %v",
+			reporte.FechaCorte.Format(time.RFC3339), reporte.RolModuloPermisos, reporte.ModuloPermisos, reporte.Modulos)
+	}
+	if len(reporte.ModulosRetenidos) > 0 {
+		log.Printf("Módulos fuera de retención conservados por seguir referenciados: %v", reporte.ModulosRetenidos)
+	}
+}
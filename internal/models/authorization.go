@@ -0,0 +1,18 @@
+package models
+
+type AuthorizationRequest struct {
+	IdUsuario    int    `json:"id_usuario" binding:"required"`
+	ModuloID     int    `json:"modulo_id"` // o bien modulo_codigo
+	ModuloCodigo string `json:"modulo_codigo"`
+	Permiso      string `json:"permiso" binding:"required"` // código del permiso, p. ej. "W", o "fichas:W" con el código del módulo
+	Sede         string `json:"sede"`                       // sede del recurso, si aplica
+	Regional     string `json:"regional"`                   // regional del recurso, si aplica
+	// Recurso concreto dentro del módulo, para evaluar permisos por instancia
+	TipoRecurso string `json:"tipo_recurso"`
+	IdRecurso   string `json:"id_recurso"`
+}
+
+type AuthorizationResponse struct {
+	Permitido bool   `json:"permitido"`
+	Motivo    string `json:"motivo"`
+}
@@ -0,0 +1,85 @@
+package models
+
+import "time"
+
+// Delegacion permite que un usuario ceda temporalmente a otro parte o la
+// totalidad de sus permisos efectivos de módulo
+type Delegacion struct {
+	ID               int                 `json:"id" gorm:"primaryKey;autoIncrement"`
+	IdOrganizacion   int                 `json:"id_organizacion" gorm:"not null;default:1;index"`
+	IdDelegante      int                 `json:"id_delegante" gorm:"not null;index"`
+	IdDelegado       int                 `json:"id_delegado" gorm:"not null;index"`
+	Todos            bool                `json:"todos" gorm:"not null;default:false"` // delega todos los permisos del delegante
+	Motivo           string              `json:"motivo" gorm:"type:text"`
+	FechaInicio      time.Time           `json:"fecha_inicio" gorm:"type:timestamp;not null"`
+	FechaFin         time.Time           `json:"fecha_fin" gorm:"type:timestamp;not null"`
+	FechaCreacion    time.Time           `json:"fecha_creacion" gorm:"type:timestamp;default:CURRENT_TIMESTAMP"`
+	FechaRevocacion  *time.Time          `json:"fecha_revocacion" gorm:"type:timestamp;default:null"`
+	MotivoRevocacion string              `json:"motivo_revocacion" gorm:"type:text"`
+	Permisos         []DelegacionPermiso `json:"permisos,omitempty" gorm:"foreignKey:IdDelegacion;constraint:OnDelete:CASCADE"`
+	Delegante        User                `json:"-" gorm:"foreignKey:IdDelegante"`
+	Delegado         User                `json:"-" gorm:"foreignKey:IdDelegado"`
+}
+
+func (Delegacion) TableName() string {
+	return "delegaciones"
+}
+
+// Activa indica si la delegación no fue revocada y está dentro de su ventana
+func (d *Delegacion) Activa(now time.Time) bool {
+	return d.FechaRevocacion == nil && !now.Before(d.FechaInicio) && now.Before(d.FechaFin)
+}
+
+// Cubre indica si la delegación incluye el permiso del módulo
+func (d *Delegacion) Cubre(moduloID int, codigo string) bool {
+	if d.Todos {
+		return true
+	}
+	for _, p := range d.Permisos {
+		if p.IdModulo == moduloID && p.PermisoTipo.Codigo == codigo {
+			return true
+		}
+	}
+	return false
+}
+
+type DelegacionPermiso struct {
+	ID                int         `json:"id" gorm:"primaryKey;autoIncrement"`
+	IdOrganizacion    int         `json:"id_organizacion" gorm:"not null;default:1;index"`
+	IdDelegacion      int         `json:"id_delegacion" gorm:"not null;index"`
+	IdModulo          int         `json:"id_modulo" gorm:"not null"`
+	IdPermisoTipo     int         `json:"id_permiso_tipo" gorm:"not null"`
+	FechaEliminacion  *time.Time  `json:"fecha_eliminacion" gorm:"type:timestamp;default:null"`
+	IdLoteEliminacion *int        `json:"-" gorm:"index"`
+	Modulo            Module      `json:"-" gorm:"foreignKey:IdModulo"`
+	PermisoTipo       PermisoTipo `json:"permiso_tipo" gorm:"foreignKey:IdPermisoTipo"`
+}
+
+func (DelegacionPermiso) TableName() string {
+	return "delegacion_permisos"
+}
+
+type CreateDelegacionRequest struct {
+	IdDelegante int                        `json:"id_delegante" binding:"required"`
+	IdDelegado  int                        `json:"id_delegado" binding:"required"`
+	Todos       bool                       `json:"todos"`
+	Permisos    []DelegacionPermisoRequest `json:"permisos"` // requerido si "todos" es falso
+	Motivo      string                     `json:"motivo"`
+	FechaInicio *time.Time                 `json:"fecha_inicio"` // por defecto, ahora
+	FechaFin    time.Time                  `json:"fecha_fin" binding:"required"`
+}
+
+type DelegacionPermisoRequest struct {
+	ModuloID      int `json:"modulo_id" binding:"required"`
+	PermisoTipoID int `json:"permiso_tipo_id" binding:"required"`
+}
+
+type RevokeDelegacionRequest struct {
+	Motivo string `json:"motivo"`
+}
+
+type DelegacionFilter struct {
+	IdDelegante int
+	IdDelegado  int
+	Activas     bool
+}
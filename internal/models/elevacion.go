@@ -0,0 +1,85 @@
+package models
+
+import "time"
+
+// Estados de una solicitud de elevación de privilegios
+const (
+	ElevacionPendiente = "pendiente"
+	ElevacionAprobada  = "aprobada"
+	ElevacionRechazada = "rechazada"
+	ElevacionExpirada  = "expirada"
+)
+
+// Duración máxima de una elevación temporal
+const MaxDuracionElevacionMinutos = 24 * 60
+
+type SolicitudElevacion struct {
+	ID              int         `json:"id" gorm:"primaryKey;autoIncrement"`
+	IdOrganizacion  int         `json:"id_organizacion" gorm:"not null;default:1;index"`
+	IdUsuario       int         `json:"id_usuario" gorm:"not null;index"`
+	IdModulo        int         `json:"id_modulo" gorm:"not null"`
+	IdPermisoTipo   int         `json:"id_permiso_tipo" gorm:"not null"`
+	DuracionMinutos int         `json:"duracion_minutos" gorm:"not null"`
+	Justificacion   string      `json:"justificacion" gorm:"type:text;not null"`
+	Estado          string      `json:"estado" gorm:"type:varchar(20);not null;default:'pendiente'"`
+	IdAprobador     *int        `json:"id_aprobador"`
+	MotivoDecision  string      `json:"motivo_decision" gorm:"type:text"`
+	FechaSolicitud  time.Time   `json:"fecha_solicitud" gorm:"type:timestamp;default:CURRENT_TIMESTAMP"`
+	FechaDecision   *time.Time  `json:"fecha_decision" gorm:"type:timestamp;default:null"`
+	FechaActivacion *time.Time  `json:"fecha_activacion" gorm:"type:timestamp;default:null"`
+	FechaExpiracion *time.Time  `json:"fecha_expiracion" gorm:"type:timestamp;default:null"`
+	Usuario         User        `json:"-" gorm:"foreignKey:IdUsuario"`
+	Aprobador       *User       `json:"-" gorm:"foreignKey:IdAprobador"`
+	Modulo          Module      `json:"modulo" gorm:"foreignKey:IdModulo"`
+	PermisoTipo     PermisoTipo `json:"permiso_tipo" gorm:"foreignKey:IdPermisoTipo"`
+}
+
+func (SolicitudElevacion) TableName() string {
+	return "solicitudes_elevacion"
+}
+
+// Activa indica si la elevación está aprobada y aún no ha expirado
+func (s *SolicitudElevacion) Activa(now time.Time) bool {
+	return s.Estado == ElevacionAprobada && s.FechaExpiracion != nil && now.Before(*s.FechaExpiracion)
+}
+
+// AprobadorElevacion designa a un usuario que puede decidir solicitudes de
+// elevación de un módulo, o de todos los módulos si IdModulo es nulo
+type AprobadorElevacion struct {
+	ID             int       `json:"id" gorm:"primaryKey;autoIncrement"`
+	IdOrganizacion int       `json:"id_organizacion" gorm:"not null;default:1;index"`
+	IdUsuario      int       `json:"id_usuario" gorm:"not null"`
+	IdModulo       *int      `json:"id_modulo"`
+	FechaCreacion  time.Time `json:"fecha_creacion" gorm:"type:timestamp;default:CURRENT_TIMESTAMP"`
+	Usuario        User      `json:"-" gorm:"foreignKey:IdUsuario"`
+	Modulo         *Module   `json:"-" gorm:"foreignKey:IdModulo"`
+}
+
+func (AprobadorElevacion) TableName() string {
+	return "aprobadores_elevacion"
+}
+
+type CreateElevacionRequest struct {
+	IdUsuario       int    `json:"id_usuario" binding:"required"`
+	ModuloID        int    `json:"modulo_id" binding:"required"`
+	PermisoTipoID   int    `json:"permiso_tipo_id" binding:"required"`
+	DuracionMinutos int    `json:"duracion_minutos" binding:"required,min=1"`
+	Justificacion   string `json:"justificacion" binding:"required"`
+}
+
+type DecisionElevacionRequest struct {
+	IdAprobador int    `json:"id_aprobador" binding:"required"`
+	Motivo      string `json:"motivo"`
+}
+
+type CreateAprobadorElevacionRequest struct {
+	IdUsuario int  `json:"id_usuario" binding:"required"`
+	ModuloID  *int `json:"modulo_id"` // nulo para aprobar en todos los módulos
+}
+
+type ElevacionFilter struct {
+	Estado    string
+	IdUsuario int
+	IdModulo  int
+	Activas   bool
+}
@@ -0,0 +1,94 @@
+package models
+
+import "time"
+
+// Grupo es un conjunto de usuarios que recibe roles y permisos de módulo
+// directos, p. ej. "Instructores Sede Norte"
+type Grupo struct {
+	ID                 int       `json:"id" gorm:"primaryKey;autoIncrement"`
+	IdOrganizacion     int       `json:"id_organizacion" gorm:"not null;default:1;uniqueIndex:idx_grupos_organizacion_nombre,priority:1"`
+	Nombre             string    `json:"nombre" gorm:"type:varchar(255);not null;uniqueIndex:idx_grupos_organizacion_nombre,priority:2"`
+	Descripcion        string    `json:"descripcion" gorm:"type:text"`
+	FechaCreacion      time.Time `json:"fecha_creacion" gorm:"type:timestamp;default:CURRENT_TIMESTAMP"`
+	FechaActualizacion time.Time `json:"fecha_actualizacion" gorm:"type:timestamp;default:CURRENT_TIMESTAMP"`
+}
+
+func (Grupo) TableName() string {
+	return "grupos"
+}
+
+type GrupoUsuario struct {
+	ID             int       `json:"id" gorm:"primaryKey;autoIncrement"`
+	IdOrganizacion int       `json:"id_organizacion" gorm:"not null;default:1;index"`
+	IdGrupo        int       `json:"id_grupo" gorm:"not null;uniqueIndex:idx_grupo_usuario"`
+	IdUsuario      int       `json:"id_usuario" gorm:"not null;uniqueIndex:idx_grupo_usuario"`
+	FechaCreacion  time.Time `json:"fecha_creacion" gorm:"type:timestamp;default:CURRENT_TIMESTAMP"`
+	Grupo          Grupo     `json:"-" gorm:"foreignKey:IdGrupo;constraint:OnDelete:CASCADE"`
+	Usuario        User      `json:"-" gorm:"foreignKey:IdUsuario"`
+}
+
+func (GrupoUsuario) TableName() string {
+	return "grupo_usuarios"
+}
+
+type GrupoRol struct {
+	ID             int       `json:"id" gorm:"primaryKey;autoIncrement"`
+	IdOrganizacion int       `json:"id_organizacion" gorm:"not null;default:1;index"`
+	IdGrupo        int       `json:"id_grupo" gorm:"not null;uniqueIndex:idx_grupo_rol"`
+	IdRol          int       `json:"id_rol" gorm:"not null;uniqueIndex:idx_grupo_rol"`
+	FechaCreacion  time.Time `json:"fecha_creacion" gorm:"type:timestamp;default:CURRENT_TIMESTAMP"`
+	Grupo          Grupo     `json:"-" gorm:"foreignKey:IdGrupo;constraint:OnDelete:CASCADE"`
+	Role           Role      `json:"-" gorm:"foreignKey:IdRol"`
+}
+
+func (GrupoRol) TableName() string {
+	return "grupo_roles"
+}
+
+type GrupoModuloPermiso struct {
+	ID                int         `json:"id" gorm:"primaryKey;autoIncrement"`
+	IdOrganizacion    int         `json:"id_organizacion" gorm:"not null;default:1;index"`
+	IdGrupo           int         `json:"id_grupo" gorm:"not null;index"`
+	IdModulo          int         `json:"id_modulo" gorm:"not null"`
+	IdPermisoTipo     int         `json:"id_permiso_tipo" gorm:"not null"`
+	Efecto            string      `json:"efecto" gorm:"type:varchar(10);not null;default:'allow'"`
+	FechaCreacion     time.Time   `json:"fecha_creacion" gorm:"type:timestamp;default:CURRENT_TIMESTAMP"`
+	FechaEliminacion  *time.Time  `json:"fecha_eliminacion" gorm:"type:timestamp;default:null"`
+	IdLoteEliminacion *int        `json:"-" gorm:"index"`
+	Grupo             Grupo       `json:"-" gorm:"foreignKey:IdGrupo;constraint:OnDelete:CASCADE"`
+	Modulo            Module      `json:"modulo" gorm:"foreignKey:IdModulo"`
+	PermisoTipo       PermisoTipo `json:"permiso_tipo" gorm:"foreignKey:IdPermisoTipo"`
+}
+
+func (GrupoModuloPermiso) TableName() string {
+	return "grupo_modulo_permisos"
+}
+
+type CreateGrupoRequest struct {
+	Nombre      string `json:"nombre" binding:"required"`
+	Descripcion string `json:"descripcion"`
+}
+
+type GrupoMiembroRequest struct {
+	IdUsuario int `json:"id_usuario" binding:"required"`
+}
+
+type GrupoRolRequest struct {
+	IdRol int `json:"id_rol" binding:"required"`
+}
+
+type AssignGrupoPermissionsRequest struct {
+	ModuloID      int    `json:"modulo_id" binding:"required"`
+	PermisoTipoID []int  `json:"permiso_tipo_id" binding:"required"`
+	Efecto        string `json:"efecto" binding:"omitempty,oneof=allow deny"` // por defecto "allow"
+}
+
+type GrupoResponse struct {
+	ID                 int       `json:"id"`
+	Nombre             string    `json:"nombre"`
+	Descripcion        string    `json:"descripcion"`
+	Roles              []Role    `json:"roles"`
+	TotalMiembros      int       `json:"total_miembros"`
+	FechaCreacion      time.Time `json:"fecha_creacion"`
+	FechaActualizacion time.Time `json:"fecha_actualizacion"`
+}
@@ -0,0 +1,31 @@
+package models
+
+import "time"
+
+// Entidades cuya eliminación lógica se registra en lotes
+const (
+	EntidadRol    = "rol"
+	EntidadModulo = "modulo"
+
+	// EntidadModuloPermiso registra los permisos que un módulo dejó de
+	// ofrecer; IdEntidad es el módulo
+	EntidadModuloPermiso = "modulo_permiso"
+
+	// EntidadPermisoTipo registra los registros retirados junto con un tipo
+	// de permiso; IdEntidad es el tipo de permiso
+	EntidadPermisoTipo = "permiso_tipo"
+)
+
+// LoteEliminacion agrupa los registros marcados como eliminados por una misma
+// operación, para que la restauración recupere exactamente esos registros y no
+// los que se habían eliminado antes por otros motivos
+type LoteEliminacion struct {
+	ID               int       `json:"id" gorm:"primaryKey;autoIncrement"`
+	Entidad          string    `json:"entidad" gorm:"type:varchar(20);not null;index:idx_lote_eliminacion_entidad"`
+	IdEntidad        int       `json:"id_entidad" gorm:"not null;index:idx_lote_eliminacion_entidad"`
+	FechaEliminacion time.Time `json:"fecha_eliminacion" gorm:"type:timestamp;not null"`
+}
+
+func (LoteEliminacion) TableName() string {
+	return "lotes_eliminacion"
+}
@@ -1,39 +1,94 @@
 package models
 
 import (
+	"regexp"
+	"strings"
 	"time"
 )
 
+// Module es una funcionalidad del sistema. Los módulos forman un árbol a
+// través de IdModuloPadre, p. ej. "Académico > Fichas > Asistencia".
 type Module struct {
 	ID                 int           `json:"id" gorm:"primaryKey;autoIncrement"`
+	IdOrganizacion     int           `json:"id_organizacion" gorm:"not null;default:1;uniqueIndex:idx_modulos_organizacion_codigo,priority:1"`
+	IdModuloPadre      *int          `json:"id_modulo_padre" gorm:"index"`
+	Codigo             string        `json:"codigo" gorm:"type:varchar(50);uniqueIndex:idx_modulos_organizacion_codigo,priority:2"` // identificador estable e inmutable, p. ej. "fichas"
 	Nombre             string        `json:"nombre" gorm:"type:varchar(255);not null"`
 	Descripcion        string        `json:"descripcion" gorm:"type:text"`
 	FechaCreacion      time.Time     `json:"fecha_creacion" gorm:"type:timestamp;default:CURRENT_TIMESTAMP"`
 	FechaActualizacion time.Time     `json:"fecha_actualizacion" gorm:"type:timestamp;default:CURRENT_TIMESTAMP"`
 	FechaEliminacion   *time.Time    `json:"fecha_eliminacion" gorm:"type:timestamp;default:null"` // Nuevo campo
+	IdLoteEliminacion  *int          `json:"-"`
 	Permisos           []PermisoTipo `json:"permisos,omitempty" gorm:"many2many:modulo_permisos;foreignKey:ID;joinForeignKey:id_modulo;References:ID;joinReferences:id_permiso_tipo"`
+	ModuloPadre        *Module       `json:"-" gorm:"foreignKey:IdModuloPadre"`
 }
 
 func (Module) TableName() string {
 	return "modulos"
 }
 
+// Longitud máxima del código de un módulo
+const MaxLongitudCodigoModulo = 50
+
+var (
+	codigoModuloValido = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]*$`)
+	separadoresCodigo  = regexp.MustCompile(`[^a-z0-9]+`)
+	sinTildes          = strings.NewReplacer("á", "a", "é", "e", "í", "i", "ó", "o", "ú", "u", "ü", "u", "ñ", "n")
+)
+
+// CodigoModuloValido indica si el código tiene el formato admitido: minúsculas,
+// dígitos, guiones y guiones bajos
+func CodigoModuloValido(codigo string) bool {
+	return len(codigo) <= MaxLongitudCodigoModulo && codigoModuloValido.MatchString(codigo)
+}
+
+// CodigoDesdeNombre genera un código a partir del nombre del módulo, p. ej.
+// "Gestión de Fichas" -> "gestion-de-fichas"
+func CodigoDesdeNombre(nombre string) string {
+	codigo := sinTildes.Replace(strings.ToLower(nombre))
+	codigo = strings.Trim(separadoresCodigo.ReplaceAllString(codigo, "-"), "-")
+	if len(codigo) > MaxLongitudCodigoModulo {
+		codigo = strings.TrimRight(codigo[:MaxLongitudCodigoModulo], "-")
+	}
+	return codigo
+}
+
+// CodigoConSufijo agrega un sufijo al código recortándolo si hace falta para
+// no superar la longitud máxima
+func CodigoConSufijo(codigo, sufijo string) string {
+	if len(codigo)+len(sufijo) > MaxLongitudCodigoModulo {
+		codigo = codigo[:MaxLongitudCodigoModulo-len(sufijo)]
+	}
+	return codigo + sufijo
+}
+
 type CreateModuleRequest struct {
-	Nombre      string `json:"nombre" binding:"required"`
-	Descripcion string `json:"descripcion"`
+	Codigo        string `json:"codigo"` // por defecto se genera a partir del nombre
+	Nombre        string `json:"nombre" binding:"required"`
+	Descripcion   string `json:"descripcion"`
+	IdModuloPadre *int   `json:"id_modulo_padre"`
+}
+
+// SetModuleParentRequest mueve un módulo bajo otro; un padre nulo lo deja en la raíz
+type SetModuleParentRequest struct {
+	IdModuloPadre *int `json:"id_modulo_padre"`
 }
 
 type ModuleResponse struct {
-	ID                 int        `json:"id"`
-	Nombre             string     `json:"nombre"`
-	Descripcion        string     `json:"descripcion"`
-	FechaCreacion      time.Time  `json:"fecha_creacion"`
-	FechaActualizacion time.Time  `json:"fecha_actualizacion"`
-	FechaEliminacion   *time.Time `json:"fecha_eliminacion,omitempty"`
+	ID                 int              `json:"id"`
+	IdModuloPadre      *int             `json:"id_modulo_padre,omitempty"`
+	Codigo             string           `json:"codigo"`
+	Nombre             string           `json:"nombre"`
+	Descripcion        string           `json:"descripcion"`
+	FechaCreacion      time.Time        `json:"fecha_creacion"`
+	FechaActualizacion time.Time        `json:"fecha_actualizacion"`
+	FechaEliminacion   *time.Time       `json:"fecha_eliminacion,omitempty"`
+	Submodulos         []ModuleResponse `json:"submodulos,omitempty"` // solo en la vista de árbol
 }
 
 type ModuleWithPermissions struct {
 	ID                 int           `json:"id"`
+	Codigo             string        `json:"codigo"`
 	Nombre             string        `json:"nombre"`
 	Descripcion        string        `json:"descripcion"`
 	Permisos           []PermisoTipo `json:"permisos"`
@@ -43,6 +98,7 @@ type ModuleWithPermissions struct {
 }
 
 type AssignModulePermissionsRequest struct {
-	ModuloID       int   `json:"modulo_id" binding:"required"`
-	PermisoTipoIDs []int `json:"permiso_tipo_ids" binding:"required"`
+	ModuloID       int    `json:"modulo_id" binding:"required_without=ModuloCodigo"`
+	ModuloCodigo   string `json:"modulo_codigo"` // alternativa a modulo_id
+	PermisoTipoIDs []int  `json:"permiso_tipo_ids" binding:"required"`
 }
@@ -3,14 +3,40 @@ package models
 import "time"
 
 type ModuloPermiso struct {
-	ID               int         `json:"id" gorm:"primaryKey;autoIncrement;type:serial"`
-	IdModulo         int         `json:"id_modulo" gorm:"not null"`
-	IdPermisoTipo    int         `json:"id_permiso_tipo" gorm:"not null"`
-	FechaEliminacion *time.Time  `json:"fecha_eliminacion" gorm:"type:timestamp;default:null"`
-	Modulo           Module      `json:"modulo" gorm:"foreignKey:IdModulo"`
-	PermisoTipo      PermisoTipo `json:"permiso_tipo" gorm:"foreignKey:IdPermisoTipo"`
+	ID                int         `json:"id" gorm:"primaryKey;autoIncrement;type:serial"`
+	IdOrganizacion    int         `json:"id_organizacion" gorm:"not null;default:1;index"`
+	IdModulo          int         `json:"id_modulo" gorm:"not null"`
+	IdPermisoTipo     int         `json:"id_permiso_tipo" gorm:"not null"`
+	FechaEliminacion  *time.Time  `json:"fecha_eliminacion" gorm:"type:timestamp;default:null"`
+	IdLoteEliminacion *int        `json:"-" gorm:"index"`
+	Modulo            Module      `json:"modulo" gorm:"foreignKey:IdModulo"`
+	PermisoTipo       PermisoTipo `json:"permiso_tipo" gorm:"foreignKey:IdPermisoTipo"`
 }
 
 func (ModuloPermiso) TableName() string {
 	return "modulo_permisos"
 }
+
+// PermisoRestaurable es un registro eliminado junto con un módulo que su
+// restauración volvería a activar
+type PermisoRestaurable struct {
+	ID            int    `json:"id"`
+	IdRol         int    `json:"id_rol,omitempty"`
+	Rol           string `json:"rol,omitempty"`
+	IdModulo      int    `json:"id_modulo"`
+	IdPermisoTipo *int   `json:"id_permiso_tipo"`
+	Codigo        string `json:"codigo,omitempty"`
+}
+
+// RestauracionModuloPreview describe lo que restauraría POST /modules/:id/restore.
+// Los registros del módulo eliminados antes, por otros motivos, no se
+// restauran y solo se cuentan.
+type RestauracionModuloPreview struct {
+	IdLoteEliminacion *int                 `json:"id_lote_eliminacion"`
+	FechaEliminacion  time.Time            `json:"fecha_eliminacion"`
+	Modulos           []ModuleResponse     `json:"modulos"`
+	PermisosModulo    []PermisoRestaurable `json:"permisos_modulo"`
+	AsignacionesRol   []PermisoRestaurable `json:"asignaciones_rol"`
+	PermisosRecurso   int64                `json:"permisos_recurso"`
+	NoRestaurados     int64                `json:"no_restaurados"`
+}
@@ -0,0 +1,40 @@
+package models
+
+import "time"
+
+// OrganizacionPorDefecto es la organización a la que pertenecen los registros
+// creados antes de que el servicio fuera multi-organización, y la que se usa
+// cuando una petición no indica ninguna
+const OrganizacionPorDefecto = 1
+
+// Organizacion es una institución que usa el servicio. Usuarios, roles,
+// módulos y asignaciones de permisos pertenecen a una organización y no son
+// visibles desde las demás.
+type Organizacion struct {
+	ID                 int       `json:"id" gorm:"primaryKey;autoIncrement"`
+	Codigo             string    `json:"codigo" gorm:"type:varchar(50);not null;unique"` // identificador estable e inmutable
+	Nombre             string    `json:"nombre" gorm:"type:varchar(255);not null"`
+	Activa             bool      `json:"activa" gorm:"not null;default:true"`
+	FechaCreacion      time.Time `json:"fecha_creacion" gorm:"type:timestamp;default:CURRENT_TIMESTAMP"`
+	FechaActualizacion time.Time `json:"fecha_actualizacion" gorm:"type:timestamp;default:CURRENT_TIMESTAMP"`
+}
+
+func (Organizacion) TableName() string {
+	return "organizaciones"
+}
+
+type CreateOrganizacionRequest struct {
+	Codigo string `json:"codigo" binding:"required"`
+	Nombre string `json:"nombre" binding:"required"`
+}
+
+type UpdateOrganizacionRequest struct {
+	Nombre string `json:"nombre" binding:"required"`
+	Activa *bool  `json:"activa"`
+}
+
+// CodigoOrganizacionValido indica si el código tiene el formato admitido, el
+// mismo que el de los códigos de módulo
+func CodigoOrganizacionValido(codigo string) bool {
+	return CodigoModuloValido(codigo)
+}
@@ -1,5 +1,9 @@
 package models
 
+import "time"
+
+// Códigos de los tipos de permiso base. El catálogo admite además códigos
+// propios de varios caracteres, p. ej. "APROBAR" o "ANULAR".
 const (
 	PermisoVer        = "R" // Ver
 	PermisoCreateEdit = "W" // Crear/Editar
@@ -7,11 +11,15 @@ const (
 	PermisoEliminar   = "D" // Eliminar
 )
 
+// Longitud máxima del código de un tipo de permiso
+const MaxLongitudCodigoPermiso = 30
+
 type PermisoTipo struct {
-	ID          int    `json:"id" gorm:"primaryKey;autoIncrement"`
-	Codigo      string `json:"codigo" gorm:"type:varchar(1);not null;unique"`
-	Nombre      string `json:"nombre" gorm:"type:varchar(50);not null"`
-	Descripcion string `json:"descripcion,omitempty" gorm:"type:varchar(255)"`
+	ID               int        `json:"id" gorm:"primaryKey;autoIncrement"`
+	Codigo           string     `json:"codigo" gorm:"type:varchar(30);not null;unique"`
+	Nombre           string     `json:"nombre" gorm:"type:varchar(50);not null"`
+	Descripcion      string     `json:"descripcion,omitempty" gorm:"type:varchar(255)"`
+	FechaEliminacion *time.Time `json:"fecha_eliminacion,omitempty" gorm:"type:timestamp;default:null"` // fecha de retiro
 }
 
 func (PermisoTipo) TableName() string {
@@ -33,6 +41,13 @@ type CreatePermisoTipoRequest struct {
 	Descripcion string `json:"descripcion"`
 }
 
+// UpdatePermisoTipoRequest actualiza el nombre y la descripción. El código no
+// se puede cambiar porque los clientes lo usan para consultar permisos.
+type UpdatePermisoTipoRequest struct {
+	Nombre      string `json:"nombre" binding:"required"`
+	Descripcion string `json:"descripcion"`
+}
+
 type PermisoTipoResponse struct {
 	ID          int    `json:"id"`
 	Codigo      string `json:"codigo"`
@@ -0,0 +1,102 @@
+package models
+
+import "time"
+
+// PlantillaRol es un conjunto con nombre de permisos de módulo a partir del
+// cual se crean roles, p. ej. "Coordinador académico"
+type PlantillaRol struct {
+	ID                 int                   `json:"id" gorm:"primaryKey;autoIncrement"`
+	IdOrganizacion     int                   `json:"id_organizacion" gorm:"not null;default:1;uniqueIndex:idx_plantillas_rol_organizacion_nombre,priority:1"`
+	Nombre             string                `json:"nombre" gorm:"type:varchar(255);not null;uniqueIndex:idx_plantillas_rol_organizacion_nombre,priority:2"`
+	Descripcion        string                `json:"descripcion" gorm:"type:text"`
+	FechaCreacion      time.Time             `json:"fecha_creacion" gorm:"type:timestamp;default:CURRENT_TIMESTAMP"`
+	FechaActualizacion time.Time             `json:"fecha_actualizacion" gorm:"type:timestamp;default:CURRENT_TIMESTAMP"`
+	Permisos           []PlantillaRolPermiso `json:"permisos" gorm:"foreignKey:IdPlantilla;constraint:OnDelete:CASCADE"`
+}
+
+func (PlantillaRol) TableName() string {
+	return "plantillas_rol"
+}
+
+// PlantillaRolPermiso es un permiso de la plantilla. Igual que en
+// RolModuloPermiso, un módulo o tipo de permiso nulo es un comodín.
+type PlantillaRolPermiso struct {
+	ID                int          `json:"id" gorm:"primaryKey;autoIncrement"`
+	IdOrganizacion    int          `json:"id_organizacion" gorm:"not null;default:1;index"`
+	IdPlantilla       int          `json:"id_plantilla" gorm:"not null;index"`
+	IdModulo          *int         `json:"id_modulo"`
+	IdPermisoTipo     *int         `json:"id_permiso_tipo"`
+	Efecto            string       `json:"efecto" gorm:"type:varchar(10);not null;default:'allow'"`
+	Alcance           string       `json:"alcance" gorm:"type:varchar(20);not null;default:'global'"`
+	FechaEliminacion  *time.Time   `json:"fecha_eliminacion,omitempty" gorm:"type:timestamp;default:null"`
+	IdLoteEliminacion *int         `json:"-" gorm:"index"`
+	Modulo            *Module      `json:"modulo,omitempty" gorm:"foreignKey:IdModulo"`
+	PermisoTipo       *PermisoTipo `json:"permiso_tipo,omitempty" gorm:"foreignKey:IdPermisoTipo"`
+}
+
+func (PlantillaRolPermiso) TableName() string {
+	return "plantilla_rol_permisos"
+}
+
+// Objetivo devuelve el módulo y tipo de permiso al que aplica la entrada,
+// usando 0 para los comodines
+func (p *PlantillaRolPermiso) Objetivo() [2]int {
+	var objetivo [2]int
+	if p.IdModulo != nil {
+		objetivo[0] = *p.IdModulo
+	}
+	if p.IdPermisoTipo != nil {
+		objetivo[1] = *p.IdPermisoTipo
+	}
+	return objetivo
+}
+
+// PlantillaPermisoRequest describe un permiso de una plantilla. Las plantillas
+// no guardan sedes ni regionales concretas, solo alcances relativos al usuario.
+type PlantillaPermisoRequest struct {
+	ModuloID      int    `json:"modulo_id" binding:"required_without=TodosModulos"`
+	PermisoTipoID int    `json:"permiso_tipo_id" binding:"required_without=TodosPermisos"`
+	TodosModulos  bool   `json:"todos_modulos"`
+	TodosPermisos bool   `json:"todos_permisos"`
+	Efecto        string `json:"efecto" binding:"omitempty,oneof=allow deny"`                        // por defecto "allow"
+	Alcance       string `json:"alcance" binding:"omitempty,oneof=global misma_sede misma_regional"` // por defecto "global"
+}
+
+// ToPermiso convierte la solicitud en una entrada de plantilla
+func (req PlantillaPermisoRequest) ToPermiso() PlantillaRolPermiso {
+	permiso := PlantillaRolPermiso{
+		Efecto:  req.Efecto,
+		Alcance: req.Alcance,
+	}
+	if permiso.Efecto == "" {
+		permiso.Efecto = EfectoPermitir
+	}
+	if permiso.Alcance == "" {
+		permiso.Alcance = AlcanceGlobal
+	}
+	if !req.TodosModulos {
+		moduloID := req.ModuloID
+		permiso.IdModulo = &moduloID
+	}
+	if !req.TodosPermisos {
+		permisoTipoID := req.PermisoTipoID
+		permiso.IdPermisoTipo = &permisoTipoID
+	}
+	return permiso
+}
+
+type CreatePlantillaRolRequest struct {
+	Nombre      string                    `json:"nombre" binding:"required"`
+	Descripcion string                    `json:"descripcion"`
+	Permisos    []PlantillaPermisoRequest `json:"permisos" binding:"required,dive"`
+}
+
+// InstantiatePlantillaRolRequest crea un rol a partir de una plantilla.
+// Permisos agrega entradas o reemplaza las de la plantilla con el mismo módulo
+// y tipo de permiso; Excluir quita entradas de la plantilla.
+type InstantiatePlantillaRolRequest struct {
+	Nombre      string                    `json:"nombre" binding:"required"`
+	Descripcion string                    `json:"descripcion"`
+	Permisos    []PlantillaPermisoRequest `json:"permisos" binding:"dive"`
+	Excluir     []PlantillaPermisoRequest `json:"excluir" binding:"dive"`
+}
@@ -0,0 +1,23 @@
+package models
+
+import "time"
+
+// PurgaReporte resume una ejecución de la purga de registros eliminados
+// lógicamente que superaron el periodo de retención. En modo simulación
+// (DryRun) informa lo que se habría eliminado sin eliminar nada.
+type PurgaReporte struct {
+	DryRun            bool      `json:"dry_run"`
+	IdOrganizacion    *int      `json:"id_organizacion,omitempty"` // nulo si abarcó todas las organizaciones
+	RetencionDias     int       `json:"retencion_dias"`
+	FechaCorte        time.Time `json:"fecha_corte"`
+	FechaEjecucion    time.Time `json:"fecha_ejecucion"`
+	RolModuloPermisos int64     `json:"rol_modulo_permisos"`
+	// RolModuloPermisosRetenidos son asignaciones fuera de retención que se
+	// conservan porque pertenecen al lote de un rol que aún puede restaurarse
+	RolModuloPermisosRetenidos int64 `json:"rol_modulo_permisos_retenidos"`
+	ValoresAlcance             int64 `json:"valores_alcance"`
+	ModuloPermisos             int64 `json:"modulo_permisos"`
+	Modulos                    []int `json:"modulos"`
+	ModulosRetenidos           []int `json:"modulos_retenidos"` // fuera de retención pero aún referenciados
+	LotesEliminacion           int64 `json:"lotes_eliminacion"`
+}
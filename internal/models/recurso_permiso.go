@@ -0,0 +1,48 @@
+package models
+
+import "time"
+
+// RecursoPermiso concede un permiso sobre un recurso concreto de un módulo
+// (p. ej. la ficha 2567890 del módulo "Fichas") a un usuario o a un rol
+type RecursoPermiso struct {
+	ID                int         `json:"id" gorm:"primaryKey;autoIncrement"`
+	IdOrganizacion    int         `json:"id_organizacion" gorm:"not null;default:1;index"`
+	IdModulo          int         `json:"id_modulo" gorm:"not null;index:idx_recurso_permiso_recurso"`
+	TipoRecurso       string      `json:"tipo_recurso" gorm:"type:varchar(100);not null;index:idx_recurso_permiso_recurso"`
+	IdRecurso         string      `json:"id_recurso" gorm:"type:varchar(255);not null;index:idx_recurso_permiso_recurso"`
+	IdPermisoTipo     int         `json:"id_permiso_tipo" gorm:"not null"`
+	IdUsuario         *int        `json:"id_usuario"`
+	IdRol             *int        `json:"id_rol"`
+	FechaCreacion     time.Time   `json:"fecha_creacion" gorm:"type:timestamp;default:CURRENT_TIMESTAMP"`
+	FechaEliminacion  *time.Time  `json:"fecha_eliminacion" gorm:"type:timestamp;default:null"`
+	IdLoteEliminacion *int        `json:"-" gorm:"index"`
+	Modulo            Module      `json:"-" gorm:"foreignKey:IdModulo"`
+	PermisoTipo       PermisoTipo `json:"permiso_tipo" gorm:"foreignKey:IdPermisoTipo"`
+	Usuario           *User       `json:"-" gorm:"foreignKey:IdUsuario"`
+	Role              *Role       `json:"-" gorm:"foreignKey:IdRol"`
+}
+
+func (RecursoPermiso) TableName() string {
+	return "recurso_permisos"
+}
+
+type CreateRecursoPermisoRequest struct {
+	ModuloID      int    `json:"modulo_id" binding:"required"`
+	TipoRecurso   string `json:"tipo_recurso" binding:"required"`
+	IdRecurso     string `json:"id_recurso" binding:"required"`
+	PermisoTipoID int    `json:"permiso_tipo_id" binding:"required"`
+	IdUsuario     *int   `json:"id_usuario"` // se debe indicar usuario o rol, no ambos
+	IdRol         *int   `json:"id_rol"`
+}
+
+type UpdateRecursoPermisoRequest struct {
+	PermisoTipoID int `json:"permiso_tipo_id" binding:"required"`
+}
+
+type RecursoPermisoFilter struct {
+	IdModulo    int
+	TipoRecurso string
+	IdRecurso   string
+	IdUsuario   int
+	IdRol       int
+}
@@ -0,0 +1,55 @@
+package models
+
+import "time"
+
+// Tipos de restricción de separación de funciones (SoD)
+const (
+	SoDTipoRoles    = "roles"    // dos roles que no pueden coincidir en un usuario
+	SoDTipoPermisos = "permisos" // dos permisos de módulo que no pueden coincidir
+)
+
+type RestriccionSoD struct {
+	ID             int       `json:"id" gorm:"primaryKey;autoIncrement"`
+	IdOrganizacion int       `json:"id_organizacion" gorm:"not null;default:1;uniqueIndex:idx_restricciones_sod_organizacion_nombre,priority:1"`
+	Nombre         string    `json:"nombre" gorm:"type:varchar(255);not null;uniqueIndex:idx_restricciones_sod_organizacion_nombre,priority:2"`
+	Descripcion    string    `json:"descripcion" gorm:"type:text"`
+	Tipo           string    `json:"tipo" gorm:"type:varchar(20);not null"`
+	IdRolA         *int      `json:"id_rol_a"`
+	IdRolB         *int      `json:"id_rol_b"`
+	IdModuloA      *int      `json:"id_modulo_a"`
+	IdPermisoTipoA *int      `json:"id_permiso_tipo_a"`
+	IdModuloB      *int      `json:"id_modulo_b"`
+	IdPermisoTipoB *int      `json:"id_permiso_tipo_b"`
+	FechaCreacion  time.Time `json:"fecha_creacion" gorm:"type:timestamp;default:CURRENT_TIMESTAMP"`
+	RolA           *Role     `json:"-" gorm:"foreignKey:IdRolA"`
+	RolB           *Role     `json:"-" gorm:"foreignKey:IdRolB"`
+}
+
+func (RestriccionSoD) TableName() string {
+	return "restricciones_sod"
+}
+
+type CreateRestriccionSoDRequest struct {
+	Nombre         string `json:"nombre" binding:"required"`
+	Descripcion    string `json:"descripcion"`
+	Tipo           string `json:"tipo" binding:"required,oneof=roles permisos"`
+	IdRolA         *int   `json:"id_rol_a"`
+	IdRolB         *int   `json:"id_rol_b"`
+	IdModuloA      *int   `json:"id_modulo_a"`
+	IdPermisoTipoA *int   `json:"id_permiso_tipo_a"`
+	IdModuloB      *int   `json:"id_modulo_b"`
+	IdPermisoTipoB *int   `json:"id_permiso_tipo_b"`
+}
+
+// ViolacionSoD describe un rol cuyo conjunto efectivo de roles o permisos
+// incumple una restricción, junto con los usuarios afectados. Si la violación
+// se debe a los grupos de un usuario, Grupos indica cuáles.
+type ViolacionSoD struct {
+	IdRestriccion int    `json:"id_restriccion"`
+	Restriccion   string `json:"restriccion"`
+	Tipo          string `json:"tipo"`
+	IdRol         int    `json:"id_rol"`
+	Rol           string `json:"rol"`
+	Usuarios      []int  `json:"usuarios"`
+	Grupos        []int  `json:"grupos,omitempty"`
+}
@@ -0,0 +1,21 @@
+package models
+
+import "time"
+
+type RolHerencia struct {
+	ID             int       `json:"id" gorm:"primaryKey;autoIncrement"`
+	IdOrganizacion int       `json:"id_organizacion" gorm:"not null;default:1;index"`
+	IdRol          int       `json:"id_rol" gorm:"not null;uniqueIndex:idx_rol_herencia"`
+	IdRolPadre     int       `json:"id_rol_padre" gorm:"not null;uniqueIndex:idx_rol_herencia"`
+	FechaCreacion  time.Time `json:"fecha_creacion" gorm:"type:timestamp;default:CURRENT_TIMESTAMP"`
+	Role           Role      `json:"-" gorm:"foreignKey:IdRol"`
+	RolPadre       Role      `json:"rol_padre" gorm:"foreignKey:IdRolPadre"`
+}
+
+func (RolHerencia) TableName() string {
+	return "rol_herencias"
+}
+
+type AddRolPadreRequest struct {
+	IdRolPadre int `json:"id_rol_padre" binding:"required"`
+}
@@ -4,29 +4,132 @@ import (
 	"time"
 )
 
+// Efectos de una asignación de permiso. Una denegación prevalece sobre
+// cualquier permiso concedido por otro rol o por herencia.
+const (
+	EfectoPermitir = "allow"
+	EfectoDenegar  = "deny"
+)
+
+// Alcances de una asignación. Un permiso global aplica a cualquier recurso;
+// los demás solo a recursos de ciertas sedes o regionales.
+const (
+	AlcanceGlobal        = "global"
+	AlcanceSede          = "sede"           // sedes listadas en Valores
+	AlcanceRegional      = "regional"       // regionales listadas en Valores
+	AlcanceMismaSede     = "misma_sede"     // la sede del usuario
+	AlcanceMismaRegional = "misma_regional" // la regional del usuario
+)
+
+// RolModuloPermiso asigna un permiso de un módulo a un rol. Un IdModulo nulo
+// aplica la asignación a todos los módulos, incluidos los creados después, y
+// un IdPermisoTipo nulo a todos los tipos de permiso. Con IncluyeSubmodulos la
+// asignación se extiende a los descendientes del módulo.
 type RolModuloPermiso struct {
-	ID               int         `json:"id" gorm:"primaryKey;autoIncrement"`
-	IdRol            int         `json:"id_rol" gorm:"not null"`
-	IdModulo         int         `json:"id_modulo" gorm:"not null"`
-	IdPermisoTipo    int         `json:"id_permiso_tipo" gorm:"not null"`
-	FechaCreacion    time.Time   `json:"fecha_creacion" gorm:"type:timestamp;default:CURRENT_TIMESTAMP"`
-	FechaEliminacion *time.Time  `json:"fecha_eliminacion" gorm:"type:timestamp;default:null"`
-	Role             Role        `json:"role" gorm:"foreignKey:IdRol"`
-	Modulo           Module      `json:"modulo" gorm:"foreignKey:IdModulo"`
-	PermisoTipo      PermisoTipo `json:"permiso_tipo" gorm:"foreignKey:IdPermisoTipo"`
+	ID                int                       `json:"id" gorm:"primaryKey;autoIncrement"`
+	IdOrganizacion    int                       `json:"id_organizacion" gorm:"not null;default:1;index"`
+	IdRol             int                       `json:"id_rol" gorm:"not null"`
+	IdModulo          *int                      `json:"id_modulo"`
+	IdPermisoTipo     *int                      `json:"id_permiso_tipo"`
+	Efecto            string                    `json:"efecto" gorm:"type:varchar(10);not null;default:'allow'"`
+	Alcance           string                    `json:"alcance" gorm:"type:varchar(20);not null;default:'global'"`
+	IncluyeSubmodulos bool                      `json:"incluye_submodulos" gorm:"not null;default:false"`
+	ValidoDesde       *time.Time                `json:"valido_desde" gorm:"type:timestamp;default:null"`
+	ValidoHasta       *time.Time                `json:"valido_hasta" gorm:"type:timestamp;default:null"`
+	FechaCreacion     time.Time                 `json:"fecha_creacion" gorm:"type:timestamp;default:CURRENT_TIMESTAMP"`
+	FechaEliminacion  *time.Time                `json:"fecha_eliminacion" gorm:"type:timestamp;default:null"`
+	IdLoteEliminacion *int                      `json:"-" gorm:"index"`
+	Role              Role                      `json:"role" gorm:"foreignKey:IdRol"`
+	Modulo            *Module                   `json:"modulo,omitempty" gorm:"foreignKey:IdModulo"`
+	PermisoTipo       *PermisoTipo              `json:"permiso_tipo,omitempty" gorm:"foreignKey:IdPermisoTipo"`
+	Valores           []RolModuloPermisoAlcance `json:"valores,omitempty" gorm:"foreignKey:IdRolModuloPermiso;constraint:OnDelete:CASCADE"`
+	Comodin           bool                      `json:"comodin,omitempty" gorm:"-"` // true en las filas obtenidas al expandir un comodín o los submódulos
 }
 
 func (RolModuloPermiso) TableName() string {
 	return "rol_modulo_permisos"
 }
 
+// Vigente indica si la asignación está dentro de su ventana de validez
+func (rmp *RolModuloPermiso) Vigente(now time.Time) bool {
+	if rmp.ValidoDesde != nil && now.Before(*rmp.ValidoDesde) {
+		return false
+	}
+	if rmp.ValidoHasta != nil && !now.Before(*rmp.ValidoHasta) {
+		return false
+	}
+	return true
+}
+
+// TodosModulos indica si la asignación aplica a todos los módulos
+func (rmp *RolModuloPermiso) TodosModulos() bool {
+	return rmp.IdModulo == nil
+}
+
+// TodosPermisos indica si la asignación aplica a todos los tipos de permiso
+func (rmp *RolModuloPermiso) TodosPermisos() bool {
+	return rmp.IdPermisoTipo == nil
+}
+
+// ValoresAlcance devuelve las sedes o regionales a las que se limita el permiso
+func (rmp *RolModuloPermiso) ValoresAlcance() []string {
+	valores := make([]string, len(rmp.Valores))
+	for i, v := range rmp.Valores {
+		valores[i] = v.Valor
+	}
+	return valores
+}
+
+type RolModuloPermisoAlcance struct {
+	ID                 int    `json:"id" gorm:"primaryKey;autoIncrement"`
+	IdOrganizacion     int    `json:"id_organizacion" gorm:"not null;default:1;index"`
+	IdRolModuloPermiso int    `json:"id_rol_modulo_permiso" gorm:"not null;index"`
+	Valor              string `json:"valor" gorm:"type:varchar(100);not null"`
+}
+
+func (RolModuloPermisoAlcance) TableName() string {
+	return "rol_modulo_permiso_alcances"
+}
+
 type RolModuloPermisoResponse struct {
 	ID            int            `json:"id"`
 	IdRol         int            `json:"id_rol"`
 	IdModulo      int            `json:"id_modulo"`
 	IdPermisoTipo int            `json:"id_permiso_tipo"`
+	Efecto        string         `json:"efecto"`
+	Alcance       string         `json:"alcance"`
+	Valores       []string       `json:"valores,omitempty"`
+	ValidoDesde   *time.Time     `json:"valido_desde,omitempty"`
+	ValidoHasta   *time.Time     `json:"valido_hasta,omitempty"`
 	FechaCreacion time.Time      `json:"fecha_creacion"`
 	Role          Role           `json:"role"`
 	Modulo        ModuleResponse `json:"modulo"`
 	PermisoTipo   PermisoTipo    `json:"permiso_tipo"`
+	Heredado      bool           `json:"heredado"` // true si el permiso proviene de un rol padre
+	Comodin       bool           `json:"comodin"`  // true si el permiso proviene de una asignación a todos los módulos o permisos, o a un módulo padre
+}
+
+// Motivos por los que una asignación existente es inconsistente con su módulo
+const (
+	InconsistenciaModuloEliminado     = "modulo_eliminado"
+	InconsistenciaPermisoNoHabilitado = "permiso_no_habilitado"
+)
+
+// AsignacionInconsistente describe una asignación activa de rol_modulo_permisos
+// cuyo módulo está eliminado o no ofrece el tipo de permiso asignado
+type AsignacionInconsistente struct {
+	IdRolModuloPermiso int    `json:"id_rol_modulo_permiso"`
+	IdRol              int    `json:"id_rol"`
+	Rol                string `json:"rol"`
+	IdModulo           int    `json:"id_modulo"`
+	Modulo             string `json:"modulo"`
+	IdPermisoTipo      *int   `json:"id_permiso_tipo"`
+	Codigo             string `json:"codigo,omitempty"`
+	Motivo             string `json:"motivo"`
+}
+
+type InconsistenciasResponse struct {
+	Total        int                       `json:"total"`
+	Reparadas    bool                      `json:"reparadas"`
+	Asignaciones []AsignacionInconsistente `json:"asignaciones"`
 }
@@ -3,11 +3,14 @@ package models
 import "time"
 
 type Role struct {
-	ID                 int       `json:"id" gorm:"primaryKey;autoIncrement"`
-	Nombre             string    `json:"nombre" gorm:"type:varchar(255);not null;unique"`
-	Descripcion        string    `json:"descripcion" gorm:"type:text"`
-	FechaCreacion      time.Time `json:"fecha_creacion"`
-	FechaActualizacion time.Time `json:"fecha_actualizacion"`
+	ID                 int        `json:"id" gorm:"primaryKey;autoIncrement"`
+	IdOrganizacion     int        `json:"id_organizacion" gorm:"not null;default:1;uniqueIndex:idx_roles_organizacion_nombre,priority:1"`
+	Nombre             string     `json:"nombre" gorm:"type:varchar(255);not null;uniqueIndex:idx_roles_organizacion_nombre,priority:2"`
+	Descripcion        string     `json:"descripcion" gorm:"type:text"`
+	FechaCreacion      time.Time  `json:"fecha_creacion"`
+	FechaActualizacion time.Time  `json:"fecha_actualizacion"`
+	FechaEliminacion   *time.Time `json:"fecha_eliminacion" gorm:"type:timestamp;default:null"`
+	IdLoteEliminacion  *int       `json:"-"`
 }
 
 func (Role) TableName() string {
@@ -20,15 +23,25 @@ type CreateRoleRequest struct {
 }
 
 type RoleResponse struct {
-	ID                 int       `json:"id"`
-	Nombre             string    `json:"nombre"`
-	Descripcion        string    `json:"descripcion"`
-	FechaCreacion      time.Time `json:"fecha_creacion"`
-	FechaActualizacion time.Time `json:"fecha_actualizacion"`
+	ID                 int        `json:"id"`
+	Nombre             string     `json:"nombre"`
+	Descripcion        string     `json:"descripcion"`
+	FechaCreacion      time.Time  `json:"fecha_creacion"`
+	FechaActualizacion time.Time  `json:"fecha_actualizacion"`
+	FechaEliminacion   *time.Time `json:"fecha_eliminacion,omitempty"`
 }
 
 type AssignRolePermissionsRequest struct {
-	RoleID        int   `json:"role_id" binding:"required"`
-	ModuloID      int   `json:"modulo_id" binding:"required"`
-	PermisoTipoID []int `json:"permiso_tipo_id" binding:"required"`
+	RoleID            int        `json:"role_id" binding:"required"`
+	ModuloID          int        `json:"modulo_id" binding:"required_without_all=TodosModulos ModuloCodigo"`
+	ModuloCodigo      string     `json:"modulo_codigo"` // alternativa a modulo_id
+	PermisoTipoID     []int      `json:"permiso_tipo_id" binding:"required_without=TodosPermisos"`
+	TodosModulos      bool       `json:"todos_modulos"`                               // aplica a todos los módulos, incluidos los creados después
+	TodosPermisos     bool       `json:"todos_permisos"`                              // aplica a todos los tipos de permiso
+	IncluyeSubmodulos bool       `json:"incluye_submodulos"`                          // se extiende a los submódulos del módulo
+	Efecto            string     `json:"efecto" binding:"omitempty,oneof=allow deny"` // por defecto "allow"
+	Alcance           string     `json:"alcance" binding:"omitempty,oneof=global sede regional misma_sede misma_regional"`
+	Valores           []string   `json:"valores"` // sedes o regionales cuando el alcance es "sede" o "regional"
+	ValidoDesde       *time.Time `json:"valido_desde"`
+	ValidoHasta       *time.Time `json:"valido_hasta"`
 }
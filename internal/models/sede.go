@@ -0,0 +1,57 @@
+package models
+
+import (
+	"strings"
+	"time"
+)
+
+// Regional agrupa las sedes de una organización
+type Regional struct {
+	ID                 int       `json:"id" gorm:"primaryKey;autoIncrement"`
+	IdOrganizacion     int       `json:"id_organizacion" gorm:"not null;default:1;uniqueIndex:idx_regionales_organizacion_clave,priority:1"`
+	Nombre             string    `json:"nombre" gorm:"type:varchar(100);not null"`
+	Clave              string    `json:"-" gorm:"type:varchar(100);not null;uniqueIndex:idx_regionales_organizacion_clave,priority:2"`
+	FechaCreacion      time.Time `json:"fecha_creacion" gorm:"type:timestamp;default:CURRENT_TIMESTAMP"`
+	FechaActualizacion time.Time `json:"fecha_actualizacion" gorm:"type:timestamp;default:CURRENT_TIMESTAMP"`
+}
+
+func (Regional) TableName() string {
+	return "regionales"
+}
+
+// Sede es una ubicación física que pertenece a una regional
+type Sede struct {
+	ID                 int       `json:"id" gorm:"primaryKey;autoIncrement"`
+	IdOrganizacion     int       `json:"id_organizacion" gorm:"not null;default:1;index"`
+	IdRegional         int       `json:"id_regional" gorm:"not null;uniqueIndex:idx_sedes_regional_clave,priority:1"`
+	Nombre             string    `json:"nombre" gorm:"type:varchar(100);not null"`
+	Clave              string    `json:"-" gorm:"type:varchar(100);not null;uniqueIndex:idx_sedes_regional_clave,priority:2"`
+	FechaCreacion      time.Time `json:"fecha_creacion" gorm:"type:timestamp;default:CURRENT_TIMESTAMP"`
+	FechaActualizacion time.Time `json:"fecha_actualizacion" gorm:"type:timestamp;default:CURRENT_TIMESTAMP"`
+	Regional           *Regional `json:"regional,omitempty" gorm:"foreignKey:IdRegional"`
+}
+
+func (Sede) TableName() string {
+	return "sedes"
+}
+
+type CreateRegionalRequest struct {
+	Nombre string `json:"nombre" binding:"required"`
+}
+
+type CreateSedeRequest struct {
+	Nombre     string `json:"nombre" binding:"required"`
+	IdRegional int    `json:"id_regional" binding:"required"`
+}
+
+// NombreCatalogo normaliza los espacios de un nombre de sede o regional
+func NombreCatalogo(nombre string) string {
+	return strings.Join(strings.Fields(nombre), " ")
+}
+
+// ClaveCatalogo devuelve la forma de comparación de un nombre de sede o
+// regional, sin mayúsculas, tildes ni espacios repetidos, de modo que
+// "Bogotá", "Bogota" y "BOGOTA" son la misma regional
+func ClaveCatalogo(nombre string) string {
+	return sinTildes.Replace(strings.ToLower(NombreCatalogo(nombre)))
+}
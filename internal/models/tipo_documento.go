@@ -0,0 +1,160 @@
+package models
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Códigos de los tipos de documento base
+const (
+	DocumentoCedulaCiudadania  = "CC"  // Cédula de ciudadanía
+	DocumentoCedulaExtranjeria = "CE"  // Cédula de extranjería
+	DocumentoTarjetaIdentidad  = "TI"  // Tarjeta de identidad
+	DocumentoPasaporte         = "PAS" // Pasaporte
+	DocumentoNIT               = "NIT" // Número de identificación tributaria
+	DocumentoPEP               = "PEP" // Permiso especial de permanencia
+)
+
+// TipoDocumento define un tipo de documento de identidad y las reglas que debe
+// cumplir su número. Patron se aplica al número ya normalizado; con
+// DigitoVerificacion el último dígito del número es el dígito de verificación
+// de la DIAN.
+type TipoDocumento struct {
+	ID                 int       `json:"id" gorm:"primaryKey;autoIncrement"`
+	Codigo             string    `json:"codigo" gorm:"type:varchar(10);not null;unique"`
+	Nombre             string    `json:"nombre" gorm:"type:varchar(100);not null"`
+	Patron             string    `json:"patron" gorm:"type:varchar(100);not null"`
+	LongitudMinima     int       `json:"longitud_minima" gorm:"not null"`
+	LongitudMaxima     int       `json:"longitud_maxima" gorm:"not null"`
+	DigitoVerificacion bool      `json:"digito_verificacion" gorm:"not null;default:false"`
+	Activo             bool      `json:"activo" gorm:"not null;default:true"`
+	FechaCreacion      time.Time `json:"fecha_creacion" gorm:"type:timestamp;default:CURRENT_TIMESTAMP"`
+}
+
+func (TipoDocumento) TableName() string {
+	return "tipos_documento"
+}
+
+type CreateTipoDocumentoRequest struct {
+	Codigo             string `json:"codigo" binding:"required"`
+	Nombre             string `json:"nombre" binding:"required"`
+	Patron             string `json:"patron" binding:"required"`
+	LongitudMinima     int    `json:"longitud_minima" binding:"required,min=1"`
+	LongitudMaxima     int    `json:"longitud_maxima" binding:"required,gtefield=LongitudMinima"`
+	DigitoVerificacion bool   `json:"digito_verificacion"`
+}
+
+// UpdateTipoDocumentoRequest actualiza las reglas del tipo; el código no se
+// puede cambiar porque lo guardan los usuarios
+type UpdateTipoDocumentoRequest struct {
+	Nombre             string `json:"nombre" binding:"required"`
+	Patron             string `json:"patron" binding:"required"`
+	LongitudMinima     int    `json:"longitud_minima" binding:"required,min=1"`
+	LongitudMaxima     int    `json:"longitud_maxima" binding:"required,gtefield=LongitudMinima"`
+	DigitoVerificacion bool   `json:"digito_verificacion"`
+	Activo             *bool  `json:"activo"`
+}
+
+// tiposDocumentoLegados relaciona las formas en que se escribía el tipo de
+// documento antes del catálogo, sin mayúsculas, tildes ni puntos, con su código
+var tiposDocumentoLegados = map[string]string{
+	"cc":                              DocumentoCedulaCiudadania,
+	"cedula":                          DocumentoCedulaCiudadania,
+	"cedula de ciudadania":            DocumentoCedulaCiudadania,
+	"cedula ciudadania":               DocumentoCedulaCiudadania,
+	"ce":                              DocumentoCedulaExtranjeria,
+	"cedula de extranjeria":           DocumentoCedulaExtranjeria,
+	"cedula extranjeria":              DocumentoCedulaExtranjeria,
+	"ti":                              DocumentoTarjetaIdentidad,
+	"tarjeta de identidad":            DocumentoTarjetaIdentidad,
+	"tarjeta identidad":               DocumentoTarjetaIdentidad,
+	"pas":                             DocumentoPasaporte,
+	"pa":                              DocumentoPasaporte,
+	"pasaporte":                       DocumentoPasaporte,
+	"nit":                             DocumentoNIT,
+	"pep":                             DocumentoPEP,
+	"permiso especial de permanencia": DocumentoPEP,
+}
+
+// CodigoTipoDocumentoLegado devuelve el código del catálogo que corresponde a
+// un tipo de documento escrito como texto libre, p. ej. "Cédula" o "C.C."
+func CodigoTipoDocumentoLegado(valor string) (string, bool) {
+	clave := ClaveCatalogo(strings.ReplaceAll(valor, ".", ""))
+	if codigo, ok := tiposDocumentoLegados[clave]; ok {
+		return codigo, true
+	}
+	// Abreviaturas escritas con espacios, p. ej. "C. C."
+	codigo, ok := tiposDocumentoLegados[strings.ReplaceAll(clave, " ", "")]
+	return codigo, ok
+}
+
+// separadoresDocumento son los caracteres que se admiten al escribir un número
+// de documento pero no forman parte de él, p. ej. "1.020.304" o "900123456-7"
+var separadoresDocumento = strings.NewReplacer(".", "", " ", "", "-", "")
+
+// NormalizarNumeroDocumento quita los separadores y pasa las letras a mayúsculas
+func NormalizarNumeroDocumento(numero string) string {
+	return strings.ToUpper(separadoresDocumento.Replace(strings.TrimSpace(numero)))
+}
+
+// ValidarNumero comprueba que el número cumpla las reglas del tipo y lo
+// devuelve normalizado
+func (t *TipoDocumento) ValidarNumero(numero string) (string, error) {
+	numero = NormalizarNumeroDocumento(numero)
+	if len(numero) < t.LongitudMinima || len(numero) > t.LongitudMaxima {
+		if t.LongitudMinima == t.LongitudMaxima {
+			return "", fmt.Errorf("el número de %s debe tener %d caracteres", t.Nombre, t.LongitudMinima)
+		}
+		return "", fmt.Errorf("el número de %s debe tener entre %d y %d caracteres",
+			t.Nombre, t.LongitudMinima, t.LongitudMaxima)
+	}
+
+	patron, err := regexp.Compile(t.Patron)
+	if err != nil {
+		return "", fmt.Errorf("el patrón del tipo de documento %s es inválido: %v", t.Codigo, err)
+	}
+	if !patron.MatchString(numero) {
+		return "", fmt.Errorf("el número de documento no tiene el formato de %s", t.Nombre)
+	}
+
+	if t.DigitoVerificacion {
+		base, dv := numero[:len(numero)-1], numero[len(numero)-1:]
+		esperado, err := DigitoVerificacionNIT(base)
+		if err != nil {
+			return "", err
+		}
+		if dv != esperado {
+			return "", fmt.Errorf("el dígito de verificación de %s no es válido", t.Nombre)
+		}
+	}
+
+	return numero, nil
+}
+
+// pesosNIT son los factores de la DIAN, aplicados desde el último dígito de la base
+var pesosNIT = []int{3, 7, 13, 17, 19, 23, 29, 37, 41, 43, 47, 53, 59, 67, 71}
+
+// DigitoVerificacionNIT calcula el dígito de verificación de un NIT con el
+// método de la DIAN (módulo 11)
+func DigitoVerificacionNIT(base string) (string, error) {
+	if base == "" || len(base) > len(pesosNIT) {
+		return "", fmt.Errorf("el NIT debe tener entre 1 y %d dígitos sin el dígito de verificación", len(pesosNIT))
+	}
+
+	suma := 0
+	for i := 0; i < len(base); i++ {
+		digito := base[len(base)-1-i]
+		if digito < '0' || digito > '9' {
+			return "", fmt.Errorf("el NIT debe contener solo números")
+		}
+		suma += int(digito-'0') * pesosNIT[i]
+	}
+
+	residuo := suma % 11
+	if residuo > 1 {
+		residuo = 11 - residuo
+	}
+	return fmt.Sprint(residuo), nil
+}
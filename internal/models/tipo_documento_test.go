@@ -0,0 +1,116 @@
+package models
+
+import "testing"
+
+func TestDigitoVerificacionNIT(t *testing.T) {
+	casos := []struct {
+		base string
+		dv   string
+	}{
+		{"800197268", "4"},
+		{"890903938", "8"},
+		{"860034313", "7"},
+		{"899999068", "1"}, // residuo 1: el dígito es el residuo
+		{"900000002", "1"}, // residuo 1
+		{"900000009", "0"}, // residuo 0
+		{"900000013", "0"}, // residuo 0
+	}
+	for _, c := range casos {
+		dv, err := DigitoVerificacionNIT(c.base)
+		if err != nil {
+			t.Errorf("DigitoVerificacionNIT(%q): error inesperado: %v", c.base, err)
+			continue
+		}
+		if dv != c.dv {
+			t.Errorf("DigitoVerificacionNIT(%q) = %s, se esperaba %s", c.base, dv, c.dv)
+		}
+	}
+}
+
+func TestDigitoVerificacionNITInvalido(t *testing.T) {
+	for _, base := range []string{"", "80019726A", "1234567890123456"} {
+		if _, err := DigitoVerificacionNIT(base); err == nil {
+			t.Errorf("DigitoVerificacionNIT(%q): se esperaba un error", base)
+		}
+	}
+}
+
+// tiposBase reproduce las reglas de los tipos de documento que crea SeedTiposDocumento
+var tiposBase = map[string]TipoDocumento{
+	DocumentoCedulaCiudadania:  {Codigo: DocumentoCedulaCiudadania, Nombre: "Cédula de ciudadanía", Patron: `^[0-9]+$`, LongitudMinima: 3, LongitudMaxima: 10},
+	DocumentoCedulaExtranjeria: {Codigo: DocumentoCedulaExtranjeria, Nombre: "Cédula de extranjería", Patron: `^[0-9]+$`, LongitudMinima: 6, LongitudMaxima: 10},
+	DocumentoTarjetaIdentidad:  {Codigo: DocumentoTarjetaIdentidad, Nombre: "Tarjeta de identidad", Patron: `^[0-9]+$`, LongitudMinima: 10, LongitudMaxima: 11},
+	DocumentoPasaporte:         {Codigo: DocumentoPasaporte, Nombre: "Pasaporte", Patron: `^[A-Z0-9]+$`, LongitudMinima: 5, LongitudMaxima: 20},
+	DocumentoNIT:               {Codigo: DocumentoNIT, Nombre: "NIT", Patron: `^[0-9]+$`, LongitudMinima: 6, LongitudMaxima: 16, DigitoVerificacion: true},
+	DocumentoPEP:               {Codigo: DocumentoPEP, Nombre: "Permiso especial de permanencia", Patron: `^[0-9]+$`, LongitudMinima: 15, LongitudMaxima: 15},
+}
+
+func TestValidarNumero(t *testing.T) {
+	casos := []struct {
+		tipo        string
+		numero      string
+		normalizado string // vacío si el número debe rechazarse
+	}{
+		{DocumentoCedulaCiudadania, "1.020.304.050", "1020304050"},
+		{DocumentoCedulaCiudadania, " 123 ", "123"},
+		{DocumentoCedulaCiudadania, "12", ""},
+		{DocumentoCedulaCiudadania, "10203040501", ""},
+		{DocumentoCedulaCiudadania, "10203A", ""},
+		{DocumentoCedulaExtranjeria, "123456", "123456"},
+		{DocumentoCedulaExtranjeria, "12345", ""},
+		{DocumentoTarjetaIdentidad, "1002003004", "1002003004"},
+		{DocumentoTarjetaIdentidad, "100200300", ""},
+		{DocumentoPasaporte, "ab-123456", "AB123456"},
+		{DocumentoPasaporte, "AB12", ""},
+		{DocumentoPasaporte, "AB_12345", ""},
+		{DocumentoNIT, "800.197.268-4", "8001972684"},
+		{DocumentoNIT, "899999068-1", "8999990681"},
+		{DocumentoNIT, "900000009-0", "9000000090"},
+		{DocumentoNIT, "800197268-5", ""},
+		{DocumentoNIT, "900000009-1", ""},
+		{DocumentoNIT, "12345", ""},
+		{DocumentoPEP, "123456789012345", "123456789012345"},
+		{DocumentoPEP, "12345678901234", ""},
+	}
+	for _, c := range casos {
+		tipo := tiposBase[c.tipo]
+		numero, err := tipo.ValidarNumero(c.numero)
+		if c.normalizado == "" {
+			if err == nil {
+				t.Errorf("%s %q: se esperaba un error, se obtuvo %q", c.tipo, c.numero, numero)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s %q: error inesperado: %v", c.tipo, c.numero, err)
+			continue
+		}
+		if numero != c.normalizado {
+			t.Errorf("%s %q: se obtuvo %q, se esperaba %q", c.tipo, c.numero, numero, c.normalizado)
+		}
+	}
+}
+
+func TestCodigoTipoDocumentoLegado(t *testing.T) {
+	casos := map[string]string{
+		"Cédula":                DocumentoCedulaCiudadania,
+		"CÉDULA DE CIUDADANÍA":  DocumentoCedulaCiudadania,
+		"C.C.":                  DocumentoCedulaCiudadania,
+		"c. c.":                 DocumentoCedulaCiudadania,
+		"cedula de extranjeria": DocumentoCedulaExtranjeria,
+		"T.I.":                  DocumentoTarjetaIdentidad,
+		"Pasaporte":             DocumentoPasaporte,
+		"nit":                   DocumentoNIT,
+		"PEP":                   DocumentoPEP,
+	}
+	for valor, esperado := range casos {
+		codigo, ok := CodigoTipoDocumentoLegado(valor)
+		if !ok || codigo != esperado {
+			t.Errorf("CodigoTipoDocumentoLegado(%q) = %q, %v; se esperaba %q", valor, codigo, ok, esperado)
+		}
+	}
+
+	if codigo, ok := CodigoTipoDocumentoLegado("Registro civil"); ok {
+		t.Errorf("CodigoTipoDocumentoLegado(\"Registro civil\") = %q; no debería tener código", codigo)
+	}
+}
@@ -8,20 +8,29 @@ import (
 )
 
 type User struct {
-	ID                 int       `json:"id" gorm:"primaryKey;autoIncrement"`
-	Nombre             string    `json:"nombre" gorm:"type:varchar(100);not null"`
-	Apellidos          string    `json:"apellidos" gorm:"type:varchar(100);not null"`
-	TipoDocumento      string    `json:"tipo_documento" gorm:"type:varchar(20);not null"`
-	NumeroDocumento    string    `json:"numero_documento" gorm:"type:varchar(20);not null;unique"`
-	Sede               string    `json:"sede" gorm:"type:varchar(100);not null"`
-	IdRol              int       `json:"id_rol" gorm:"not null"`
-	Role               Role      `json:"role" gorm:"foreignKey:IdRol"`
-	Regional           string    `json:"regional" gorm:"type:varchar(100);not null"`
-	Correo             string    `json:"correo" gorm:"type:varchar(100);not null;unique"`
-	Telefono           string    `json:"telefono" gorm:"type:varchar(20)"`
-	Contraseña         string    `json:"-" gorm:"column:contraseña;type:varchar(255);not null"`
-	FechaCreacion      time.Time `json:"fecha_creacion" gorm:"type:timestamp;default:CURRENT_TIMESTAMP"`
-	FechaActualizacion time.Time `json:"fecha_actualizacion" gorm:"type:timestamp;default:CURRENT_TIMESTAMP"`
+	ID                 int        `json:"id" gorm:"primaryKey;autoIncrement"`
+	IdOrganizacion     int        `json:"id_organizacion" gorm:"not null;default:1;uniqueIndex:idx_usuarios_organizacion_tipo_numero,priority:1;uniqueIndex:idx_usuarios_organizacion_correo,priority:1"`
+	Nombre             string     `json:"nombre" gorm:"type:varchar(100);not null"`
+	Apellidos          string     `json:"apellidos" gorm:"type:varchar(100);not null"`
+	TipoDocumento      string     `json:"tipo_documento" gorm:"type:varchar(20);not null;uniqueIndex:idx_usuarios_organizacion_tipo_numero,priority:2"` // código del catálogo de tipos de documento
+	NumeroDocumento    string     `json:"numero_documento" gorm:"type:varchar(20);not null;uniqueIndex:idx_usuarios_organizacion_tipo_numero,priority:3"`
+	Sede               string     `json:"sede" gorm:"type:varchar(100);not null"` // nombre de la sede del catálogo
+	IdSede             *int       `json:"id_sede" gorm:"index"`
+	CatalogoSede       *Sede      `json:"-" gorm:"foreignKey:IdSede"`
+	IdRol              int        `json:"id_rol" gorm:"not null"`
+	Role               Role       `json:"role" gorm:"foreignKey:IdRol"`
+	RolValidoDesde     *time.Time `json:"rol_valido_desde" gorm:"type:timestamp;default:null"`
+	RolValidoHasta     *time.Time `json:"rol_valido_hasta" gorm:"type:timestamp;default:null"`
+	Estado             string     `json:"estado" gorm:"type:varchar(20);not null;default:'activo';index"`
+	Regional           string     `json:"regional" gorm:"type:varchar(100);not null"` // nombre de la regional del catálogo
+	IdRegional         *int       `json:"id_regional" gorm:"index"`
+	CatalogoRegional   *Regional  `json:"-" gorm:"foreignKey:IdRegional"`
+	Correo             string     `json:"correo" gorm:"type:varchar(100);not null;uniqueIndex:idx_usuarios_organizacion_correo,priority:2"`
+	Telefono           string     `json:"telefono" gorm:"type:varchar(20)"`
+	Contraseña         string     `json:"-" gorm:"column:contraseña;type:varchar(255);not null"`
+	FechaCreacion      time.Time  `json:"fecha_creacion" gorm:"type:timestamp;default:CURRENT_TIMESTAMP"`
+	FechaActualizacion time.Time  `json:"fecha_actualizacion" gorm:"type:timestamp;default:CURRENT_TIMESTAMP"`
+	FechaEliminacion   *time.Time `json:"fecha_eliminacion" gorm:"type:timestamp;default:null"`
 }
 
 func (User) TableName() string {
@@ -37,6 +46,22 @@ func (u *User) BeforeCreate(tx *gorm.DB) error {
 	return nil
 }
 
+// RolVigente indica si la asignación del rol del usuario está dentro de su ventana de validez
+func (u *User) RolVigente(now time.Time) bool {
+	if u.RolValidoDesde != nil && now.Before(*u.RolValidoDesde) {
+		return false
+	}
+	if u.RolValidoHasta != nil && !now.Before(*u.RolValidoHasta) {
+		return false
+	}
+	return true
+}
+
+// Activo indica si la cuenta puede autenticarse y ejercer permisos
+func (u *User) Activo() bool {
+	return u.Estado == EstadoActivo
+}
+
 func (u *User) ValidatePassword(password string) bool {
 	err := bcrypt.CompareHashAndPassword([]byte(u.Contraseña), []byte(password))
 	return err == nil
@@ -44,42 +69,51 @@ func (u *User) ValidatePassword(password string) bool {
 
 // Requests structs se mantienen igual que en el código original
 type CreateUserRequest struct {
-	Nombre          string `json:"nombre" binding:"required"`
-	Apellidos       string `json:"apellidos" binding:"required"`
-	TipoDocumento   string `json:"tipo_documento" binding:"required"`
-	NumeroDocumento string `json:"numero_documento" binding:"required"`
-	Sede            string `json:"sede" binding:"required"`
-	IdRol           int    `json:"id_rol" binding:"required"`
-	Regional        string `json:"regional" binding:"required"`
-	Correo          string `json:"correo" binding:"required,email"`
-	Telefono        string `json:"telefono" binding:"required"`
-	Contraseña      string `json:"contraseña" binding:"required,min=6"`
+	Nombre          string     `json:"nombre" binding:"required"`
+	Apellidos       string     `json:"apellidos" binding:"required"`
+	TipoDocumento   string     `json:"tipo_documento" binding:"required"`
+	NumeroDocumento string     `json:"numero_documento" binding:"required"`
+	IdSede          int        `json:"id_sede" binding:"required"` // la regional es la de la sede
+	IdRol           int        `json:"id_rol" binding:"required"`
+	Correo          string     `json:"correo" binding:"required,email"`
+	Telefono        string     `json:"telefono" binding:"required"`
+	Contraseña      string     `json:"contraseña" binding:"required,min=6"`
+	RolValidoDesde  *time.Time `json:"rol_valido_desde"`
+	RolValidoHasta  *time.Time `json:"rol_valido_hasta"`
+	Estado          string     `json:"estado" binding:"omitempty,oneof=pendiente activo"` // por defecto "activo"
 }
 
 type UpdateUserRequest struct {
-	Nombre          string `json:"nombre" binding:"required"`
-	Apellidos       string `json:"apellidos" binding:"required"`
-	TipoDocumento   string `json:"tipo_documento" binding:"required"`
-	NumeroDocumento string `json:"numero_documento" binding:"required"`
-	Sede            string `json:"sede" binding:"required"`
-	Regional        string `json:"regional" binding:"required"`
-	Correo          string `json:"correo" binding:"required,email"`
-	Telefono        string `json:"telefono" binding:"required"`
-	IdRol           int    `json:"id_rol" binding:"required"`
+	Nombre          string     `json:"nombre" binding:"required"`
+	Apellidos       string     `json:"apellidos" binding:"required"`
+	TipoDocumento   string     `json:"tipo_documento" binding:"required"`
+	NumeroDocumento string     `json:"numero_documento" binding:"required"`
+	IdSede          int        `json:"id_sede" binding:"required"` // la regional es la de la sede
+	Correo          string     `json:"correo" binding:"required,email"`
+	Telefono        string     `json:"telefono" binding:"required"`
+	IdRol           int        `json:"id_rol" binding:"required"`
+	RolValidoDesde  *time.Time `json:"rol_valido_desde"`
+	RolValidoHasta  *time.Time `json:"rol_valido_hasta"`
 }
 
 type UserResponse struct {
-	ID                 int       `json:"id"`
-	Nombre             string    `json:"nombre"`
-	Apellidos          string    `json:"apellidos"`
-	TipoDocumento      string    `json:"tipo_documento"`
-	NumeroDocumento    string    `json:"numero_documento"`
-	Sede               string    `json:"sede"`
-	IdRol              int       `json:"id_rol"`
-	Role               Role      `json:"role"`
-	Regional           string    `json:"regional"`
-	Correo             string    `json:"correo"`
-	Telefono           string    `json:"telefono"`
-	FechaCreacion      time.Time `json:"fecha_creacion"`
-	FechaActualizacion time.Time `json:"fecha_actualizacion"`
+	ID                 int        `json:"id"`
+	Nombre             string     `json:"nombre"`
+	Apellidos          string     `json:"apellidos"`
+	TipoDocumento      string     `json:"tipo_documento"`
+	NumeroDocumento    string     `json:"numero_documento"`
+	IdSede             *int       `json:"id_sede"`
+	Sede               string     `json:"sede"`
+	IdRol              int        `json:"id_rol"`
+	Role               Role       `json:"role"`
+	RolValidoDesde     *time.Time `json:"rol_valido_desde,omitempty"`
+	RolValidoHasta     *time.Time `json:"rol_valido_hasta,omitempty"`
+	Estado             string     `json:"estado"`
+	IdRegional         *int       `json:"id_regional"`
+	Regional           string     `json:"regional"`
+	Correo             string     `json:"correo"`
+	Telefono           string     `json:"telefono"`
+	FechaCreacion      time.Time  `json:"fecha_creacion"`
+	FechaActualizacion time.Time  `json:"fecha_actualizacion"`
+	FechaEliminacion   *time.Time `json:"fecha_eliminacion,omitempty"`
 }
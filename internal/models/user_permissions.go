@@ -1,5 +1,7 @@
 package models
 
+import "time"
+
 type UserPermissionsResponse struct {
 	ID              int             `json:"id"`
 	Nombre          string          `json:"nombre"`
@@ -9,6 +11,7 @@ type UserPermissionsResponse struct {
 	Correo          string          `json:"correo"`
 	Sede            string          `json:"sede"`
 	Regional        string          `json:"regional"`
+	Estado          string          `json:"estado"`
 	Role            RolePermissions `json:"rol"`
 }
 
@@ -18,10 +21,37 @@ type RolePermissions struct {
 	ModuloPermisos []ModuloPermissions `json:"modulos_permisos"`
 }
 
+// Orígenes posibles de un permiso efectivo
+const (
+	OrigenRol       = "rol"
+	OrigenHeredado  = "heredado"
+	OrigenElevacion = "elevacion"
+	OrigenDelegado  = "delegado"
+	OrigenGrupo     = "grupo"
+)
+
 type ModuloPermissions struct {
-	ID       int      `json:"id"`
-	Nombre   string   `json:"nombre"`
-	Permisos []string `json:"permisos"` // ["R", "W", "X"]
+	ID        int             `json:"id"`
+	Codigo    string          `json:"codigo"`
+	Nombre    string          `json:"nombre"`
+	Permisos  []string        `json:"permisos"`  // ["R", "W", "X"]
+	Denegados []string        `json:"denegados"` // permisos bloqueados por una denegación explícita
+	Detalle   []PermisoOrigen `json:"detalle"`
+}
+
+// PermisoOrigen indica de dónde proviene cada permiso efectivo del módulo
+type PermisoOrigen struct {
+	Codigo       string     `json:"codigo"`
+	Efecto       string     `json:"efecto"`
+	Alcance      string     `json:"alcance"`
+	Valores      []string   `json:"valores,omitempty"`
+	Origen       string     `json:"origen"`
+	IdRolOrigen  int        `json:"id_rol_origen,omitempty"`
+	IdGrupo      int        `json:"id_grupo,omitempty"`
+	IdElevacion  int        `json:"id_elevacion,omitempty"`
+	IdDelegacion int        `json:"id_delegacion,omitempty"`
+	IdDelegante  int        `json:"id_delegante,omitempty"`
+	ValidoHasta  *time.Time `json:"valido_hasta,omitempty"`
 }
 
 type UsersPermissionsListResponse struct {
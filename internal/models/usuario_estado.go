@@ -0,0 +1,55 @@
+package models
+
+import "time"
+
+// Estados del ciclo de vida de la cuenta de un usuario
+const (
+	EstadoPendiente  = "pendiente"  // creado, aún sin habilitar
+	EstadoActivo     = "activo"     // puede autenticarse y ejercer sus permisos
+	EstadoSuspendido = "suspendido" // inhabilitado temporalmente, conserva su rol
+	EstadoBloqueado  = "bloqueado"  // inhabilitado por seguridad
+	EstadoRetirado   = "retirado"   // ya no pertenece a la institución
+)
+
+// transicionesEstado indica a qué estados se puede pasar desde cada estado
+var transicionesEstado = map[string][]string{
+	EstadoPendiente:  {EstadoActivo, EstadoRetirado},
+	EstadoActivo:     {EstadoSuspendido, EstadoBloqueado, EstadoRetirado},
+	EstadoSuspendido: {EstadoActivo, EstadoBloqueado, EstadoRetirado},
+	EstadoBloqueado:  {EstadoActivo, EstadoRetirado},
+	EstadoRetirado:   {EstadoPendiente},
+}
+
+// TransicionPermitida indica si un usuario puede pasar del estado desde al estado hacia
+func TransicionPermitida(desde, hacia string) bool {
+	for _, estado := range transicionesEstado[desde] {
+		if estado == hacia {
+			return true
+		}
+	}
+	return false
+}
+
+// UsuarioEstadoHistorial registra cada cambio de estado de un usuario
+type UsuarioEstadoHistorial struct {
+	ID             int       `json:"id" gorm:"primaryKey;autoIncrement"`
+	IdOrganizacion int       `json:"id_organizacion" gorm:"not null;default:1;index"`
+	IdUsuario      int       `json:"id_usuario" gorm:"not null;index"`
+	EstadoAnterior string    `json:"estado_anterior" gorm:"type:varchar(20);not null"`
+	EstadoNuevo    string    `json:"estado_nuevo" gorm:"type:varchar(20);not null"`
+	Motivo         string    `json:"motivo" gorm:"type:text;not null"`
+	IdActor        int       `json:"id_actor" gorm:"not null"`
+	Fecha          time.Time `json:"fecha" gorm:"type:timestamp;default:CURRENT_TIMESTAMP"`
+	Usuario        User      `json:"-" gorm:"foreignKey:IdUsuario"`
+	Actor          User      `json:"-" gorm:"foreignKey:IdActor"`
+}
+
+func (UsuarioEstadoHistorial) TableName() string {
+	return "usuario_estado_historial"
+}
+
+type CambiarEstadoUsuarioRequest struct {
+	Estado  string `json:"estado" binding:"required,oneof=pendiente activo suspendido bloqueado retirado"`
+	Motivo  string `json:"motivo" binding:"required"`
+	IdActor int    `json:"id_actor" binding:"required"`
+}
@@ -0,0 +1,261 @@
+package repository
+
+import (
+	"auth-service/internal/models"
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type AuthorizationRepository struct {
+	db *gorm.DB
+}
+
+func NewAuthorizationRepository(db *gorm.DB) *AuthorizationRepository {
+	return &AuthorizationRepository{db: db}
+}
+
+// WithContext devuelve una copia del repositorio cuyas consultas usan el
+// contexto indicado, incluida la organización de la petición
+func (r *AuthorizationRepository) WithContext(ctx context.Context) *AuthorizationRepository {
+	return &AuthorizationRepository{db: r.db.WithContext(ctx)}
+}
+
+// Check evalúa si el usuario puede ejercer el permiso sobre un recurso del módulo.
+// Un permiso a nivel de módulo cubre todos sus recursos; si no existe, se buscan
+// elevaciones, delegaciones y permisos sobre el recurso concreto. Una denegación
+// aplicable a los roles del usuario prevalece siempre.
+func (r *AuthorizationRepository) Check(req models.AuthorizationRequest) (*models.AuthorizationResponse, error) {
+	var user models.User
+	if err := r.db.Where("fecha_eliminacion IS NULL").First(&user, req.IdUsuario).Error; err != nil {
+		return nil, fmt.Errorf("usuario no encontrado: %v", err)
+	}
+	if !user.Activo() {
+		return &models.AuthorizationResponse{
+			Permitido: false,
+			Motivo:    fmt.Sprintf("la cuenta del usuario está %s", user.Estado),
+		}, nil
+	}
+
+	// El permiso puede indicar el módulo por código, p. ej. "fichas:W"
+	permiso := req.Permiso
+	if moduloCodigo, codigoPermiso, found := strings.Cut(req.Permiso, ":"); found {
+		if req.ModuloCodigo != "" && !strings.EqualFold(req.ModuloCodigo, moduloCodigo) {
+			return nil, fmt.Errorf("el permiso %s no corresponde al módulo %s", req.Permiso, req.ModuloCodigo)
+		}
+		req.ModuloCodigo, permiso = moduloCodigo, codigoPermiso
+	}
+	moduloID, err := resolveModuleID(r.db, req.ModuloID, req.ModuloCodigo)
+	if err != nil {
+		return nil, err
+	}
+	req.ModuloID = moduloID
+
+	now := time.Now()
+	codigo := strings.ToUpper(permiso)
+
+	decision, roleIDs, err := userRoleDecision(r.db, &user, req, codigo, now)
+	if err != nil {
+		return nil, err
+	}
+	switch decision {
+	case decisionDenegada:
+		return &models.AuthorizationResponse{
+			Permitido: false,
+			Motivo:    "permiso denegado explícitamente",
+		}, nil
+	case decisionPermitida:
+		return &models.AuthorizationResponse{Permitido: true, Motivo: "permiso concedido"}, nil
+	}
+
+	// Elevaciones temporales aprobadas
+	elevaciones, err := activeElevations(r.db, user.ID, now)
+	if err != nil {
+		return nil, err
+	}
+	for _, elevacion := range elevaciones {
+		if elevacion.IdModulo == req.ModuloID && elevacion.PermisoTipo.Codigo == codigo {
+			return &models.AuthorizationResponse{
+				Permitido: true,
+				Motivo:    fmt.Sprintf("permiso concedido por elevación temporal #%d", elevacion.ID),
+			}, nil
+		}
+	}
+
+	// Delegaciones: el delegante debe tener hoy el permiso sobre el mismo recurso
+	delegaciones, err := activeDelegationsTo(r.db, user.ID, now)
+	if err != nil {
+		return nil, err
+	}
+	for _, delegacion := range delegaciones {
+		if !delegacion.Cubre(req.ModuloID, codigo) {
+			continue
+		}
+		decisionDelegante, _, err := userRoleDecision(r.db, &delegacion.Delegante, req, codigo, now)
+		if err != nil {
+			return nil, err
+		}
+		if decisionDelegante == decisionPermitida {
+			return &models.AuthorizationResponse{
+				Permitido: true,
+				Motivo:    fmt.Sprintf("permiso delegado por el usuario #%d (delegación #%d)", delegacion.IdDelegante, delegacion.ID),
+			}, nil
+		}
+	}
+
+	// Permisos sobre el recurso concreto
+	if req.TipoRecurso != "" && req.IdRecurso != "" {
+		tiene, err := hasResourceGrant(r.db, user.ID, roleIDs, req.ModuloID, req.TipoRecurso, req.IdRecurso, codigo)
+		if err != nil {
+			return nil, err
+		}
+		if tiene {
+			return &models.AuthorizationResponse{
+				Permitido: true,
+				Motivo:    "permiso concedido sobre el recurso",
+			}, nil
+		}
+	}
+
+	return &models.AuthorizationResponse{
+		Permitido: false,
+		Motivo:    "el usuario no tiene el permiso sobre este recurso",
+	}, nil
+}
+
+// Resultado de evaluar los permisos de módulo de los roles de un usuario
+const (
+	sinDecision = iota
+	decisionPermitida
+	decisionDenegada
+)
+
+// userRoleDecision carga las asignaciones vigentes de los roles y grupos del
+// usuario sobre el módulo, las evalúa con roleDecision y devuelve también los
+// roles considerados
+func userRoleDecision(db *gorm.DB, user *models.User, req models.AuthorizationRequest, codigo string, now time.Time) (int, []int, error) {
+	// Roles propios (mientras la asignación esté vigente), heredados y de grupos
+	fuentes, grupoIDs, err := userRoleSources(db, user, now)
+	if err != nil {
+		return sinDecision, nil, err
+	}
+	roleIDs := make([]int, 0, len(fuentes))
+	for id := range fuentes {
+		roleIDs = append(roleIDs, id)
+	}
+
+	// Permisos directos de los grupos
+	var grupoPermisos []models.GrupoModuloPermiso
+	if len(grupoIDs) > 0 {
+		if err := db.Joins("JOIN permiso_tipos ON permiso_tipos.id = grupo_modulo_permisos.id_permiso_tipo").
+			Where("grupo_modulo_permisos.id_grupo IN ? AND grupo_modulo_permisos.id_modulo = ?", grupoIDs, req.ModuloID).
+			Where("grupo_modulo_permisos.fecha_eliminacion IS NULL").
+			Where("permiso_tipos.codigo = ?", codigo).
+			Scopes(asignacionActiva("grupo_modulo_permisos")).
+			Find(&grupoPermisos).Error; err != nil {
+			return sinDecision, nil, err
+		}
+	}
+
+	if len(roleIDs) == 0 {
+		return roleDecision(grupoPermisos, nil, user, req.Sede, req.Regional, now), roleIDs, nil
+	}
+
+	// Los módulos padre cuyas asignaciones pueden extenderse a este módulo
+	moduloAncestorIDs, err := moduleAncestorIDs(db, req.ModuloID)
+	if err != nil {
+		return sinDecision, nil, err
+	}
+
+	var grants []models.RolModuloPermiso
+	// Las asignaciones comodín y las de módulos padre que incluyen submódulos
+	// cubren el módulo si está activo y el tipo de permiso está habilitado en él
+	if err := db.Joins("LEFT JOIN permiso_tipos ON permiso_tipos.id = rol_modulo_permisos.id_permiso_tipo").
+		Where("rol_modulo_permisos.id_rol IN ?", roleIDs).
+		Scopes(grantActivo).
+		Where("rol_modulo_permisos.id_modulo = ? OR "+
+			"(rol_modulo_permisos.incluye_submodulos AND rol_modulo_permisos.id_modulo IN ?) OR "+
+			"(rol_modulo_permisos.id_modulo IS NULL AND EXISTS (SELECT 1 FROM modulos WHERE modulos.id = ? AND modulos.fecha_eliminacion IS NULL))",
+			req.ModuloID, moduloAncestorIDs, req.ModuloID).
+		Where("permiso_tipos.codigo = ? OR rol_modulo_permisos.id_permiso_tipo IS NULL", codigo).
+		Where("(rol_modulo_permisos.id_modulo = ? AND rol_modulo_permisos.id_permiso_tipo IS NOT NULL) OR EXISTS ("+
+			"SELECT 1 FROM modulo_permisos mp JOIN permiso_tipos pt ON pt.id = mp.id_permiso_tipo "+
+			"WHERE mp.id_modulo = ? AND mp.fecha_eliminacion IS NULL AND pt.codigo = ?)",
+			req.ModuloID, req.ModuloID, codigo).
+		Scopes(grantVigente(now)).
+		Preload("Valores").
+		Find(&grants).Error; err != nil {
+		return sinDecision, nil, err
+	}
+
+	return roleDecision(grupoPermisos, grants, user, req.Sede, req.Regional, now), roleIDs, nil
+}
+
+// roleDecision evalúa los permisos directos de los grupos y las asignaciones
+// de los roles del usuario sobre un recurso de la sede y regional indicadas.
+// Una denegación aplicable prevalece sobre cualquier permiso; las asignaciones
+// eliminadas, fuera de su ventana de validez o cuyo alcance no cubre el
+// recurso no se consideran.
+func roleDecision(grupoPermisos []models.GrupoModuloPermiso, grants []models.RolModuloPermiso, user *models.User, sede, regional string, now time.Time) int {
+	decision := sinDecision
+	for _, gp := range grupoPermisos {
+		if gp.FechaEliminacion != nil {
+			continue
+		}
+		if gp.Efecto == models.EfectoDenegar {
+			return decisionDenegada
+		}
+		decision = decisionPermitida
+	}
+
+	for _, grant := range grants {
+		if grant.FechaEliminacion != nil || !grant.Vigente(now) {
+			continue
+		}
+		if !grantMatchesResource(&grant, user, sede, regional) {
+			continue
+		}
+		if grant.Efecto == models.EfectoDenegar {
+			return decisionDenegada
+		}
+		decision = decisionPermitida
+	}
+	return decision
+}
+
+// grantMatchesResource indica si el alcance de una asignación cubre el recurso.
+// Si el recurso no informa la sede o regional que el alcance necesita, solo las
+// denegaciones se consideran aplicables, para no conceder acceso por omisión.
+func grantMatchesResource(grant *models.RolModuloPermiso, user *models.User, sede, regional string) bool {
+	var valor string
+	var permitidos []string
+
+	switch grant.Alcance {
+	case models.AlcanceSede:
+		valor, permitidos = sede, grant.ValoresAlcance()
+	case models.AlcanceRegional:
+		valor, permitidos = regional, grant.ValoresAlcance()
+	case models.AlcanceMismaSede:
+		valor, permitidos = sede, []string{user.Sede}
+	case models.AlcanceMismaRegional:
+		valor, permitidos = regional, []string{user.Regional}
+	default:
+		return true
+	}
+
+	if strings.TrimSpace(valor) == "" {
+		return grant.Efecto == models.EfectoDenegar
+	}
+
+	// Se compara como en el catálogo de sedes y regionales, sin distinguir
+	// mayúsculas, tildes ni espacios
+	for _, p := range permitidos {
+		if models.ClaveCatalogo(p) == models.ClaveCatalogo(valor) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,135 @@
+package repository
+
+import (
+	"auth-service/internal/models"
+	"strconv"
+
+	"gorm.io/gorm"
+)
+
+// mismoValor compara una columna opcional con un valor, donde nil representa
+// el comodín (columna nula)
+func mismoValor(columna string, valor *int) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if valor == nil {
+			return db.Where(columna + " IS NULL")
+		}
+		return db.Where(columna+" = ?", *valor)
+	}
+}
+
+// expandGrants reemplaza las asignaciones a todos los módulos o a todos los
+// tipos de permiso por una fila concreta por cada módulo activo y tipo de
+// permiso habilitado en ese módulo que cubren, y agrega las filas de los
+// submódulos de las asignaciones que los incluyen. Las asignaciones concretas
+// se devuelven sin cambios y deben traer precargados Modulo y PermisoTipo.
+func expandGrants(db *gorm.DB, grants []models.RolModuloPermiso) ([]models.RolModuloPermiso, error) {
+	var modulos []models.Module
+	var hijos map[int][]int
+	var tipos []models.PermisoTipo
+	var habilitados map[int]map[int]bool
+	modulosCargados, tiposCargados := false, false
+
+	cargarModulos := func() error {
+		if modulosCargados {
+			return nil
+		}
+		if err := db.Where("fecha_eliminacion IS NULL").Order("id").Find(&modulos).Error; err != nil {
+			return err
+		}
+		hijos = make(map[int][]int)
+		for i, m := range modulos {
+			if m.IdModuloPadre != nil {
+				hijos[*m.IdModuloPadre] = append(hijos[*m.IdModuloPadre], i)
+			}
+		}
+		modulosCargados = true
+		return nil
+	}
+
+	expanded := make([]models.RolModuloPermiso, 0, len(grants))
+	for _, grant := range grants {
+		concreta := !grant.TodosModulos() && !grant.TodosPermisos()
+		if concreta {
+			expanded = append(expanded, grant)
+			if !grant.IncluyeSubmodulos {
+				continue
+			}
+		}
+
+		grantModulos := []models.Module{}
+		if grant.TodosModulos() {
+			if err := cargarModulos(); err != nil {
+				return nil, err
+			}
+			grantModulos = modulos
+		} else if grant.Modulo != nil {
+			if !concreta {
+				grantModulos = append(grantModulos, *grant.Modulo)
+			}
+			if grant.IncluyeSubmodulos {
+				if err := cargarModulos(); err != nil {
+					return nil, err
+				}
+				// Recorrer los descendientes activos del módulo
+				pendientes := append([]int(nil), hijos[grant.Modulo.ID]...)
+				visitados := map[int]bool{grant.Modulo.ID: true}
+				for len(pendientes) > 0 {
+					i := pendientes[0]
+					pendientes = pendientes[1:]
+					if visitados[modulos[i].ID] {
+						continue
+					}
+					visitados[modulos[i].ID] = true
+					grantModulos = append(grantModulos, modulos[i])
+					pendientes = append(pendientes, hijos[modulos[i].ID]...)
+				}
+			}
+		}
+
+		grantTipos := []models.PermisoTipo{}
+		if grant.TodosPermisos() {
+			if !tiposCargados {
+				if err := db.Where("fecha_eliminacion IS NULL").Order("id").Find(&tipos).Error; err != nil {
+					return nil, err
+				}
+				tiposCargados = true
+			}
+			grantTipos = tipos
+		} else if grant.PermisoTipo != nil {
+			grantTipos = append(grantTipos, *grant.PermisoTipo)
+		}
+
+		if habilitados == nil {
+			var err error
+			if habilitados, err = permisosHabilitados(db); err != nil {
+				return nil, err
+			}
+		}
+
+		for i := range grantModulos {
+			for j := range grantTipos {
+				if !habilitados[grantModulos[i].ID][grantTipos[j].ID] {
+					continue
+				}
+				fila := grant
+				fila.IdModulo = &grantModulos[i].ID
+				fila.IdPermisoTipo = &grantTipos[j].ID
+				fila.Modulo = &grantModulos[i]
+				fila.PermisoTipo = &grantTipos[j]
+				fila.Comodin = true
+				expanded = append(expanded, fila)
+			}
+		}
+	}
+
+	return expanded, nil
+}
+
+// idOTodos formatea un identificador opcional para los registros de log
+func idOTodos(id *int) string {
+	if id == nil {
+		return "todos"
+	}
+	return strconv.Itoa(*id)
+}
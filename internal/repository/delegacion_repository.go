@@ -0,0 +1,196 @@
+package repository
+
+import (
+	"auth-service/internal/models"
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type DelegacionRepository struct {
+	db *gorm.DB
+}
+
+func NewDelegacionRepository(db *gorm.DB) *DelegacionRepository {
+	return &DelegacionRepository{db: db}
+}
+
+// WithContext devuelve una copia del repositorio cuyas consultas usan el
+// contexto indicado, incluida la organización de la petición
+func (r *DelegacionRepository) WithContext(ctx context.Context) *DelegacionRepository {
+	return &DelegacionRepository{db: r.db.WithContext(ctx)}
+}
+
+// Create registra la delegación tras comprobar que el delegante tiene hoy
+// cada uno de los permisos que cede
+func (r *DelegacionRepository) Create(delegacion *models.Delegacion) error {
+	if delegacion.IdDelegante == delegacion.IdDelegado {
+		return fmt.Errorf("un usuario no puede delegarse permisos a sí mismo")
+	}
+	if !delegacion.FechaFin.After(delegacion.FechaInicio) {
+		return fmt.Errorf("la fecha de fin debe ser posterior a la fecha de inicio")
+	}
+	if !delegacion.FechaFin.After(time.Now()) {
+		return fmt.Errorf("la fecha de fin debe ser futura")
+	}
+	if !delegacion.Todos && len(delegacion.Permisos) == 0 {
+		return fmt.Errorf("debe indicar los permisos a delegar o delegar todos")
+	}
+
+	var delegante models.User
+	if err := r.db.Where("fecha_eliminacion IS NULL").First(&delegante, delegacion.IdDelegante).Error; err != nil {
+		return fmt.Errorf("usuario delegante no encontrado: %v", err)
+	}
+	var delegado models.User
+	if err := r.db.Where("fecha_eliminacion IS NULL").First(&delegado, delegacion.IdDelegado).Error; err != nil {
+		return fmt.Errorf("usuario delegado no encontrado: %v", err)
+	}
+
+	propios := make(permisosPorModulo)
+	if err := addRolePermissions(r.db, propios, &delegante, time.Now()); err != nil {
+		return err
+	}
+
+	if delegacion.Todos {
+		delegacion.Permisos = nil
+	}
+	for _, p := range delegacion.Permisos {
+		var permisoTipo models.PermisoTipo
+		if err := r.db.Where("fecha_eliminacion IS NULL").First(&permisoTipo, p.IdPermisoTipo).Error; err != nil {
+			return fmt.Errorf("tipo de permiso no encontrado: %v", err)
+		}
+		if !propios.permitido(p.IdModulo, permisoTipo.Codigo) {
+			return fmt.Errorf("el delegante no tiene el permiso %s en el módulo %d", permisoTipo.Codigo, p.IdModulo)
+		}
+	}
+
+	if err := r.db.Create(delegacion).Error; err != nil {
+		return err
+	}
+
+	log.Printf("Delegación creada: id=%d delegante=%d delegado=%d todos=%t permisos=%d desde=%s hasta=%s",
+		delegacion.ID, delegacion.IdDelegante, delegacion.IdDelegado, delegacion.Todos, len(delegacion.Permisos),
+		delegacion.FechaInicio.Format(time.RFC3339), delegacion.FechaFin.Format(time.RFC3339))
+	return nil
+}
+
+func (r *DelegacionRepository) GetByID(id int) (*models.Delegacion, error) {
+	var delegacion models.Delegacion
+	err := r.db.Preload("Permisos", "fecha_eliminacion IS NULL").
+		Preload("Permisos.PermisoTipo").
+		First(&delegacion, id).Error
+	if err != nil {
+		return nil, fmt.Errorf("delegación no encontrada: %v", err)
+	}
+	return &delegacion, nil
+}
+
+func (r *DelegacionRepository) GetAll(filter models.DelegacionFilter) ([]models.Delegacion, error) {
+	query := r.db.Preload("Permisos", "fecha_eliminacion IS NULL").Preload("Permisos.PermisoTipo")
+	if filter.IdDelegante != 0 {
+		query = query.Where("id_delegante = ?", filter.IdDelegante)
+	}
+	if filter.IdDelegado != 0 {
+		query = query.Where("id_delegado = ?", filter.IdDelegado)
+	}
+	if filter.Activas {
+		query = query.Scopes(delegacionActiva(time.Now()))
+	}
+
+	var delegaciones []models.Delegacion
+	err := query.Order("fecha_creacion DESC").Find(&delegaciones).Error
+	return delegaciones, err
+}
+
+// Revoke finaliza la delegación antes de su fecha de fin
+func (r *DelegacionRepository) Revoke(id int, motivo string) error {
+	now := time.Now()
+	result := r.db.Model(&models.Delegacion{}).
+		Where("id = ? AND fecha_revocacion IS NULL AND fecha_fin > ?", id, now).
+		Updates(map[string]interface{}{
+			"fecha_revocacion":  now,
+			"motivo_revocacion": motivo,
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("delegación no encontrada, ya revocada o finalizada")
+	}
+
+	log.Printf("Delegación revocada: id=%d motivo=%q", id, motivo)
+	return nil
+}
+
+func delegacionActiva(now time.Time) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("fecha_revocacion IS NULL AND fecha_inicio <= ? AND fecha_fin > ?", now, now)
+	}
+}
+
+// activeDelegationsTo devuelve las delegaciones vigentes recibidas por un usuario
+func activeDelegationsTo(db *gorm.DB, userID int, now time.Time) ([]models.Delegacion, error) {
+	var delegaciones []models.Delegacion
+	err := db.Where("id_delegado = ?", userID).
+		Where("id_delegante IN (?)", usuariosActivos(db)).
+		Scopes(delegacionActiva(now)).
+		Preload("Permisos", "fecha_eliminacion IS NULL").
+		Preload("Permisos.PermisoTipo").
+		Preload("Delegante").
+		Find(&delegaciones).Error
+	return delegaciones, err
+}
+
+// addDelegatedPermissions agrega los permisos delegados al usuario. Cada
+// permiso se limita a lo que el delegante tiene hoy por sus roles, de modo
+// que una delegación nunca excede los permisos actuales del delegante.
+func addDelegatedPermissions(db *gorm.DB, permisos permisosPorModulo, user *models.User, now time.Time) error {
+	delegaciones, err := activeDelegationsTo(db, user.ID, now)
+	if err != nil {
+		return err
+	}
+
+	for _, delegacion := range delegaciones {
+		propios := make(permisosPorModulo)
+		if err := addRolePermissions(db, propios, &delegacion.Delegante, now); err != nil {
+			return err
+		}
+
+		for moduloID, mp := range propios {
+			for _, origen := range mp.Detalle {
+				if origen.Efecto != models.EfectoPermitir ||
+					!propios.permitido(moduloID, origen.Codigo) ||
+					!delegacion.Cubre(moduloID, origen.Codigo) {
+					continue
+				}
+
+				// Los alcances relativos al delegante se fijan a su sede o regional
+				alcance := origen.Alcance
+				switch alcance {
+				case models.AlcanceMismaSede:
+					alcance = models.AlcanceSede
+				case models.AlcanceMismaRegional:
+					alcance = models.AlcanceRegional
+				}
+
+				validoHasta := delegacion.FechaFin
+				modulo := models.Module{ID: moduloID, Codigo: mp.Codigo, Nombre: mp.Nombre}
+				permisos.agregar(modulo, models.PermisoOrigen{
+					Codigo:       origen.Codigo,
+					Efecto:       models.EfectoPermitir,
+					Alcance:      alcance,
+					Valores:      origen.Valores,
+					Origen:       models.OrigenDelegado,
+					IdDelegacion: delegacion.ID,
+					IdDelegante:  delegacion.IdDelegante,
+					ValidoHasta:  &validoHasta,
+				})
+			}
+		}
+	}
+
+	return nil
+}
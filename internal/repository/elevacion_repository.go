@@ -0,0 +1,240 @@
+package repository
+
+import (
+	"auth-service/internal/models"
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type ElevacionRepository struct {
+	db *gorm.DB
+}
+
+func NewElevacionRepository(db *gorm.DB) *ElevacionRepository {
+	return &ElevacionRepository{db: db}
+}
+
+// WithContext devuelve una copia del repositorio cuyas consultas usan el
+// contexto indicado, incluida la organización de la petición
+func (r *ElevacionRepository) WithContext(ctx context.Context) *ElevacionRepository {
+	return &ElevacionRepository{db: r.db.WithContext(ctx)}
+}
+
+func (r *ElevacionRepository) Create(solicitud *models.SolicitudElevacion) error {
+	if solicitud.DuracionMinutos > models.MaxDuracionElevacionMinutos {
+		return fmt.Errorf("la duración máxima de una elevación es de %d minutos", models.MaxDuracionElevacionMinutos)
+	}
+
+	var user models.User
+	if err := r.db.Where("fecha_eliminacion IS NULL").First(&user, solicitud.IdUsuario).Error; err != nil {
+		return fmt.Errorf("usuario no encontrado: %v", err)
+	}
+
+	var module models.Module
+	if err := r.db.Where("fecha_eliminacion IS NULL").First(&module, solicitud.IdModulo).Error; err != nil {
+		return fmt.Errorf("módulo no encontrado: %v", err)
+	}
+
+	var permisoTipo models.PermisoTipo
+	if err := r.db.Where("fecha_eliminacion IS NULL").First(&permisoTipo, solicitud.IdPermisoTipo).Error; err != nil {
+		return fmt.Errorf("tipo de permiso no encontrado: %v", err)
+	}
+	if err := validatePermisosHabilitados(r.db, module.ID, []int{permisoTipo.ID}); err != nil {
+		return err
+	}
+
+	// Evitar solicitudes duplicadas mientras haya una pendiente o activa
+	var exists bool
+	if err := r.db.Model(&models.SolicitudElevacion{}).
+		Where("id_usuario = ? AND id_modulo = ? AND id_permiso_tipo = ?",
+			solicitud.IdUsuario, solicitud.IdModulo, solicitud.IdPermisoTipo).
+		Where("estado = ? OR (estado = ? AND fecha_expiracion > ?)",
+			models.ElevacionPendiente, models.ElevacionAprobada, time.Now()).
+		Select("count(*) > 0").
+		Scan(&exists).Error; err != nil {
+		return err
+	}
+	if exists {
+		return fmt.Errorf("ya existe una solicitud pendiente o activa para este permiso")
+	}
+
+	solicitud.Estado = models.ElevacionPendiente
+	if err := r.db.Create(solicitud).Error; err != nil {
+		return err
+	}
+
+	log.Printf("Solicitud de elevación creada: id=%d usuario=%d modulo=%d permiso=%s duracion=%dm",
+		solicitud.ID, solicitud.IdUsuario, solicitud.IdModulo, permisoTipo.Codigo, solicitud.DuracionMinutos)
+	return nil
+}
+
+func (r *ElevacionRepository) GetByID(id int) (*models.SolicitudElevacion, error) {
+	var solicitud models.SolicitudElevacion
+	err := r.db.Preload("Modulo").Preload("PermisoTipo").First(&solicitud, id).Error
+	if err != nil {
+		return nil, fmt.Errorf("solicitud no encontrada: %v", err)
+	}
+	return &solicitud, nil
+}
+
+func (r *ElevacionRepository) GetAll(filter models.ElevacionFilter) ([]models.SolicitudElevacion, error) {
+	query := r.db.Preload("Modulo").Preload("PermisoTipo")
+	if filter.Estado != "" {
+		query = query.Where("estado = ?", filter.Estado)
+	}
+	if filter.IdUsuario != 0 {
+		query = query.Where("id_usuario = ?", filter.IdUsuario)
+	}
+	if filter.IdModulo != 0 {
+		query = query.Where("id_modulo = ?", filter.IdModulo)
+	}
+	if filter.Activas {
+		query = query.Where("estado = ? AND fecha_expiracion > ?", models.ElevacionAprobada, time.Now())
+	}
+
+	var solicitudes []models.SolicitudElevacion
+	err := query.Order("fecha_solicitud DESC").Find(&solicitudes).Error
+	return solicitudes, err
+}
+
+// Approve aprueba la solicitud y la activa de inmediato durante la duración solicitada
+func (r *ElevacionRepository) Approve(id, aprobadorID int, motivo string) error {
+	return r.decide(id, aprobadorID, motivo, models.ElevacionAprobada)
+}
+
+func (r *ElevacionRepository) Reject(id, aprobadorID int, motivo string) error {
+	return r.decide(id, aprobadorID, motivo, models.ElevacionRechazada)
+}
+
+func (r *ElevacionRepository) decide(id, aprobadorID int, motivo, estado string) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var solicitud models.SolicitudElevacion
+		if err := tx.First(&solicitud, id).Error; err != nil {
+			return fmt.Errorf("solicitud no encontrada: %v", err)
+		}
+		if solicitud.Estado != models.ElevacionPendiente {
+			return fmt.Errorf("la solicitud ya fue decidida (estado: %s)", solicitud.Estado)
+		}
+		if solicitud.IdUsuario == aprobadorID {
+			return fmt.Errorf("un usuario no puede decidir su propia solicitud")
+		}
+
+		var aprobador models.User
+		if err := tx.Where("fecha_eliminacion IS NULL").First(&aprobador, aprobadorID).Error; err != nil {
+			return fmt.Errorf("aprobador no encontrado: %v", err)
+		}
+		if !aprobador.Activo() {
+			return fmt.Errorf("el aprobador debe tener una cuenta activa")
+		}
+
+		var designado bool
+		if err := tx.Model(&models.AprobadorElevacion{}).
+			Where("id_usuario = ? AND (id_modulo IS NULL OR id_modulo = ?)", aprobadorID, solicitud.IdModulo).
+			Select("count(*) > 0").
+			Scan(&designado).Error; err != nil {
+			return err
+		}
+		if !designado {
+			return fmt.Errorf("el usuario no es aprobador designado para este módulo")
+		}
+
+		now := time.Now()
+		updates := map[string]interface{}{
+			"estado":          estado,
+			"id_aprobador":    aprobadorID,
+			"motivo_decision": motivo,
+			"fecha_decision":  now,
+		}
+		if estado == models.ElevacionAprobada {
+			updates["fecha_activacion"] = now
+			updates["fecha_expiracion"] = now.Add(time.Duration(solicitud.DuracionMinutos) * time.Minute)
+		}
+
+		if err := tx.Model(&solicitud).Updates(updates).Error; err != nil {
+			return err
+		}
+
+		log.Printf("Solicitud de elevación %s: id=%d usuario=%d aprobador=%d",
+			estado, solicitud.ID, solicitud.IdUsuario, aprobadorID)
+		return nil
+	})
+}
+
+// ExpireElevations marca como expiradas las elevaciones aprobadas cuyo plazo terminó
+func (r *ElevacionRepository) ExpireElevations(now time.Time) (int, error) {
+	var expired []models.SolicitudElevacion
+	if err := r.db.Where("estado = ? AND fecha_expiracion <= ?", models.ElevacionAprobada, now).
+		Find(&expired).Error; err != nil {
+		return 0, err
+	}
+	if len(expired) == 0 {
+		return 0, nil
+	}
+
+	ids := make([]int, len(expired))
+	for i, s := range expired {
+		ids[i] = s.ID
+	}
+
+	if err := r.db.Model(&models.SolicitudElevacion{}).
+		Where("id IN ?", ids).
+		Update("estado", models.ElevacionExpirada).Error; err != nil {
+		return 0, err
+	}
+
+	for _, s := range expired {
+		log.Printf("Elevación expirada: id=%d usuario=%d modulo=%d permiso=%d",
+			s.ID, s.IdUsuario, s.IdModulo, s.IdPermisoTipo)
+	}
+
+	return len(expired), nil
+}
+
+func (r *ElevacionRepository) CreateAprobador(aprobador *models.AprobadorElevacion) error {
+	var user models.User
+	if err := r.db.Where("fecha_eliminacion IS NULL").First(&user, aprobador.IdUsuario).Error; err != nil {
+		return fmt.Errorf("usuario no encontrado: %v", err)
+	}
+
+	if aprobador.IdModulo != nil {
+		var module models.Module
+		if err := r.db.Where("fecha_eliminacion IS NULL").First(&module, *aprobador.IdModulo).Error; err != nil {
+			return fmt.Errorf("módulo no encontrado: %v", err)
+		}
+	}
+
+	return r.db.Create(aprobador).Error
+}
+
+func (r *ElevacionRepository) GetAprobadores() ([]models.AprobadorElevacion, error) {
+	var aprobadores []models.AprobadorElevacion
+	err := r.db.Find(&aprobadores).Error
+	return aprobadores, err
+}
+
+func (r *ElevacionRepository) DeleteAprobador(id int) error {
+	result := r.db.Delete(&models.AprobadorElevacion{}, id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("aprobador no encontrado")
+	}
+	return nil
+}
+
+// activeElevations devuelve las elevaciones vigentes de un usuario
+func activeElevations(db *gorm.DB, userID int, now time.Time) ([]models.SolicitudElevacion, error) {
+	var elevaciones []models.SolicitudElevacion
+	err := db.Where("id_usuario = ? AND estado = ? AND fecha_expiracion > ?",
+		userID, models.ElevacionAprobada, now).
+		Scopes(asignacionActiva("solicitudes_elevacion")).
+		Preload("Modulo").
+		Preload("PermisoTipo").
+		Find(&elevaciones).Error
+	return elevaciones, err
+}
@@ -0,0 +1,297 @@
+package repository
+
+import (
+	"auth-service/internal/models"
+	"context"
+	"fmt"
+
+	"gorm.io/gorm"
+)
+
+type GrupoRepository struct {
+	db *gorm.DB
+}
+
+func NewGrupoRepository(db *gorm.DB) *GrupoRepository {
+	return &GrupoRepository{db: db}
+}
+
+// WithContext devuelve una copia del repositorio cuyas consultas usan el
+// contexto indicado, incluida la organización de la petición
+func (r *GrupoRepository) WithContext(ctx context.Context) *GrupoRepository {
+	return &GrupoRepository{db: r.db.WithContext(ctx)}
+}
+
+func (r *GrupoRepository) Create(grupo *models.Grupo) error {
+	var exists bool
+	if err := r.db.Model(&models.Grupo{}).
+		Where("LOWER(nombre) = LOWER(?)", grupo.Nombre).
+		Select("count(*) > 0").
+		Scan(&exists).Error; err != nil {
+		return err
+	}
+
+	if exists {
+		return fmt.Errorf("ya existe un grupo con este nombre")
+	}
+
+	return r.db.Create(grupo).Error
+}
+
+func (r *GrupoRepository) GetAll() ([]models.Grupo, error) {
+	var grupos []models.Grupo
+	err := r.db.Find(&grupos).Error
+	return grupos, err
+}
+
+func (r *GrupoRepository) GetByID(id int) (*models.Grupo, error) {
+	var grupo models.Grupo
+	err := r.db.First(&grupo, id).Error
+	if err != nil {
+		return nil, fmt.Errorf("grupo no encontrado: %v", err)
+	}
+	return &grupo, nil
+}
+
+func (r *GrupoRepository) Update(grupo *models.Grupo) error {
+	var exists bool
+	if err := r.db.Model(&models.Grupo{}).
+		Where("LOWER(nombre) = LOWER(?) AND id != ?", grupo.Nombre, grupo.ID).
+		Select("count(*) > 0").
+		Scan(&exists).Error; err != nil {
+		return err
+	}
+
+	if exists {
+		return fmt.Errorf("ya existe un grupo con este nombre")
+	}
+
+	return r.db.Model(grupo).Updates(map[string]interface{}{
+		"nombre":      grupo.Nombre,
+		"descripcion": grupo.Descripcion,
+	}).Error
+}
+
+func (r *GrupoRepository) Delete(id int) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		for _, model := range []interface{}{&models.GrupoUsuario{}, &models.GrupoRol{}, &models.GrupoModuloPermiso{}} {
+			if err := tx.Where("id_grupo = ?", id).Delete(model).Error; err != nil {
+				return err
+			}
+		}
+
+		result := tx.Delete(&models.Grupo{}, id)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return fmt.Errorf("grupo no encontrado")
+		}
+		return nil
+	})
+}
+
+func (r *GrupoRepository) GetRoles(grupoID int) ([]models.Role, error) {
+	var roles []models.Role
+	err := r.db.Joins("JOIN grupo_roles ON grupo_roles.id_rol = roles.id").
+		Where("grupo_roles.id_grupo = ? AND roles.fecha_eliminacion IS NULL", grupoID).
+		Find(&roles).Error
+	return roles, err
+}
+
+func (r *GrupoRepository) CountMembers(grupoID int) (int, error) {
+	var count int64
+	err := r.db.Model(&models.GrupoUsuario{}).Where("id_grupo = ?", grupoID).Count(&count).Error
+	return int(count), err
+}
+
+func (r *GrupoRepository) GetMembers(grupoID int) ([]models.User, error) {
+	if _, err := r.GetByID(grupoID); err != nil {
+		return nil, err
+	}
+
+	var users []models.User
+	err := r.db.Preload("Role").
+		Joins("JOIN grupo_usuarios ON grupo_usuarios.id_usuario = usuarios.id").
+		Where("grupo_usuarios.id_grupo = ? AND usuarios.fecha_eliminacion IS NULL", grupoID).
+		Find(&users).Error
+	return users, err
+}
+
+func (r *GrupoRepository) AddMember(grupoID, userID int) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.First(&models.Grupo{}, grupoID).Error; err != nil {
+			return fmt.Errorf("grupo no encontrado: %v", err)
+		}
+		var user models.User
+		if err := tx.Where("fecha_eliminacion IS NULL").First(&user, userID).Error; err != nil {
+			return fmt.Errorf("usuario no encontrado: %v", err)
+		}
+
+		var exists bool
+		if err := tx.Model(&models.GrupoUsuario{}).
+			Where("id_grupo = ? AND id_usuario = ?", grupoID, userID).
+			Select("count(*) > 0").
+			Scan(&exists).Error; err != nil {
+			return err
+		}
+		if exists {
+			return fmt.Errorf("el usuario ya pertenece al grupo")
+		}
+
+		if err := tx.Create(&models.GrupoUsuario{IdGrupo: grupoID, IdUsuario: userID}).Error; err != nil {
+			return err
+		}
+
+		return checkUserSoD(tx, user.ID, user.IdRol)
+	})
+}
+
+func (r *GrupoRepository) RemoveMember(grupoID, userID int) error {
+	result := r.db.Where("id_grupo = ? AND id_usuario = ?", grupoID, userID).Delete(&models.GrupoUsuario{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("el usuario no pertenece al grupo")
+	}
+	return nil
+}
+
+func (r *GrupoRepository) AddRole(grupoID, roleID int) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.First(&models.Grupo{}, grupoID).Error; err != nil {
+			return fmt.Errorf("grupo no encontrado: %v", err)
+		}
+		if err := tx.Where("fecha_eliminacion IS NULL").First(&models.Role{}, roleID).Error; err != nil {
+			return fmt.Errorf("rol no encontrado: %v", err)
+		}
+
+		var exists bool
+		if err := tx.Model(&models.GrupoRol{}).
+			Where("id_grupo = ? AND id_rol = ?", grupoID, roleID).
+			Select("count(*) > 0").
+			Scan(&exists).Error; err != nil {
+			return err
+		}
+		if exists {
+			return fmt.Errorf("el grupo ya tiene este rol")
+		}
+
+		if err := tx.Create(&models.GrupoRol{IdGrupo: grupoID, IdRol: roleID}).Error; err != nil {
+			return err
+		}
+
+		return checkGroupMembersSoD(tx, grupoID)
+	})
+}
+
+func (r *GrupoRepository) RemoveRole(grupoID, roleID int) error {
+	result := r.db.Where("id_grupo = ? AND id_rol = ?", grupoID, roleID).Delete(&models.GrupoRol{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("el grupo no tiene el rol especificado")
+	}
+	return nil
+}
+
+func (r *GrupoRepository) AssignModulePermission(grupoID int, req models.AssignGrupoPermissionsRequest) error {
+	efecto := req.Efecto
+	if efecto == "" {
+		efecto = models.EfectoPermitir
+	}
+
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.First(&models.Grupo{}, grupoID).Error; err != nil {
+			return fmt.Errorf("grupo no encontrado: %v", err)
+		}
+
+		var module models.Module
+		if err := tx.Where("fecha_eliminacion IS NULL").First(&module, req.ModuloID).Error; err != nil {
+			return fmt.Errorf("módulo no encontrado: %v", err)
+		}
+
+		var count int64
+		if err := tx.Model(&models.PermisoTipo{}).Where("id IN ? AND fecha_eliminacion IS NULL", req.PermisoTipoID).Count(&count).Error; err != nil {
+			return err
+		}
+		if int(count) != len(req.PermisoTipoID) {
+			return fmt.Errorf("algunos permisos no existen")
+		}
+		if err := validatePermisosHabilitados(tx, req.ModuloID, req.PermisoTipoID); err != nil {
+			return err
+		}
+
+		// Reemplazar los permisos con el mismo efecto; cada permiso tiene una
+		// única asignación por grupo y módulo
+		if err := tx.Where("id_grupo = ? AND id_modulo = ? AND (efecto = ? OR id_permiso_tipo IN ?)",
+			grupoID, req.ModuloID, efecto, req.PermisoTipoID).
+			Delete(&models.GrupoModuloPermiso{}).Error; err != nil {
+			return err
+		}
+
+		for _, permisoID := range req.PermisoTipoID {
+			if err := tx.Create(&models.GrupoModuloPermiso{
+				IdGrupo:       grupoID,
+				IdModulo:      req.ModuloID,
+				IdPermisoTipo: permisoID,
+				Efecto:        efecto,
+			}).Error; err != nil {
+				return err
+			}
+		}
+
+		return checkGroupMembersSoD(tx, grupoID)
+	})
+}
+
+func (r *GrupoRepository) GetModulePermissions(grupoID int) ([]models.GrupoModuloPermiso, error) {
+	if _, err := r.GetByID(grupoID); err != nil {
+		return nil, err
+	}
+
+	var permisos []models.GrupoModuloPermiso
+	err := r.db.Where("id_grupo = ? AND fecha_eliminacion IS NULL", grupoID).
+		Preload("Modulo").
+		Preload("PermisoTipo").
+		Find(&permisos).Error
+	return permisos, err
+}
+
+func (r *GrupoRepository) RemoveModulePermission(grupoID, moduleID, permisoTipoID int) error {
+	result := r.db.Where("id_grupo = ? AND id_modulo = ? AND id_permiso_tipo = ? AND fecha_eliminacion IS NULL", grupoID, moduleID, permisoTipoID).
+		Delete(&models.GrupoModuloPermiso{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("no se encontró el permiso especificado")
+	}
+	return nil
+}
+
+// userGroupIDs devuelve los grupos a los que pertenece el usuario
+func userGroupIDs(db *gorm.DB, userID int) ([]int, error) {
+	var grupoIDs []int
+	err := db.Model(&models.GrupoUsuario{}).Where("id_usuario = ?", userID).Pluck("id_grupo", &grupoIDs).Error
+	return grupoIDs, err
+}
+
+// checkGroupMembersSoD valida la separación de funciones de cada miembro del grupo
+func checkGroupMembersSoD(db *gorm.DB, grupoID int) error {
+	var members []models.User
+	if err := db.Joins("JOIN grupo_usuarios ON grupo_usuarios.id_usuario = usuarios.id").
+		Where("grupo_usuarios.id_grupo = ? AND usuarios.fecha_eliminacion IS NULL", grupoID).
+		Find(&members).Error; err != nil {
+		return err
+	}
+
+	for _, member := range members {
+		if err := checkUserSoD(db, member.ID, member.IdRol); err != nil {
+			return fmt.Errorf("usuario %d: %v", member.ID, err)
+		}
+	}
+	return nil
+}
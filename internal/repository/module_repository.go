@@ -2,7 +2,9 @@ package repository
 
 import (
 	"auth-service/internal/models"
+	"context"
 	"fmt"
+	"strings"
 	"time"
 
 	"gorm.io/gorm"
@@ -16,6 +18,12 @@ func NewModuleRepository(db *gorm.DB) *ModuleRepository {
 	return &ModuleRepository{db: db}
 }
 
+// WithContext devuelve una copia del repositorio cuyas consultas usan el
+// contexto indicado, incluida la organización de la petición
+func (r *ModuleRepository) WithContext(ctx context.Context) *ModuleRepository {
+	return &ModuleRepository{db: r.db.WithContext(ctx)}
+}
+
 // repository/module_repository.go
 func (r *ModuleRepository) Create(module *models.Module) error {
 	var exists bool
@@ -30,9 +38,132 @@ func (r *ModuleRepository) Create(module *models.Module) error {
 		return fmt.Errorf("ya existe un módulo con este nombre")
 	}
 
+	if module.IdModuloPadre != nil {
+		var parent models.Module
+		if err := r.db.Where("fecha_eliminacion IS NULL").First(&parent, *module.IdModuloPadre).Error; err != nil {
+			return fmt.Errorf("módulo padre no encontrado: %v", err)
+		}
+	}
+
+	// El código identifica al módulo entre entornos y no cambia después de
+	// crearlo; los códigos de módulos eliminados siguen reservados
+	if module.Codigo == "" {
+		codigo, err := codigoModuloDisponible(r.db, models.CodigoDesdeNombre(module.Nombre))
+		if err != nil {
+			return err
+		}
+		module.Codigo = codigo
+	} else {
+		module.Codigo = strings.ToLower(strings.TrimSpace(module.Codigo))
+		if !models.CodigoModuloValido(module.Codigo) {
+			return fmt.Errorf("código de módulo inválido: use hasta %d minúsculas, dígitos, guiones o guiones bajos",
+				models.MaxLongitudCodigoModulo)
+		}
+		if err := r.db.Model(&models.Module{}).
+			Where("codigo = ?", module.Codigo).
+			Select("count(*) > 0").
+			Scan(&exists).Error; err != nil {
+			return err
+		}
+		if exists {
+			return fmt.Errorf("ya existe un módulo con el código %s", module.Codigo)
+		}
+	}
+
 	return r.db.Create(module).Error
 }
 
+func (r *ModuleRepository) GetByCodigo(codigo string) (*models.Module, error) {
+	var module models.Module
+	err := r.db.Where("codigo = ? AND fecha_eliminacion IS NULL", strings.ToLower(codigo)).First(&module).Error
+	if err != nil {
+		return nil, err
+	}
+	return &module, nil
+}
+
+// ResolveID devuelve el id del módulo indicado por id o por código
+func (r *ModuleRepository) ResolveID(id int, codigo string) (int, error) {
+	return resolveModuleID(r.db, id, codigo)
+}
+
+// SetParent mueve un módulo bajo otro, o a la raíz si parentID es nil,
+// evitando ciclos en el árbol
+func (r *ModuleRepository) SetParent(id int, parentID *int) error {
+	var module models.Module
+	if err := r.db.Where("fecha_eliminacion IS NULL").First(&module, id).Error; err != nil {
+		return fmt.Errorf("módulo no encontrado: %v", err)
+	}
+
+	if parentID != nil {
+		if *parentID == id {
+			return fmt.Errorf("un módulo no puede ser su propio padre")
+		}
+
+		var parent models.Module
+		if err := r.db.Where("fecha_eliminacion IS NULL").First(&parent, *parentID).Error; err != nil {
+			return fmt.Errorf("módulo padre no encontrado: %v", err)
+		}
+
+		descendantIDs, err := moduleDescendantIDs(r.db, id)
+		if err != nil {
+			return err
+		}
+		for _, descendantID := range descendantIDs {
+			if descendantID == *parentID {
+				return fmt.Errorf("la asignación crearía un ciclo: el módulo %d es descendiente de %d", *parentID, id)
+			}
+		}
+	}
+
+	return r.db.Model(&module).Update("id_modulo_padre", parentID).Error
+}
+
+// GetTree devuelve los módulos activos anidados bajo sus padres
+func (r *ModuleRepository) GetTree() ([]models.ModuleResponse, error) {
+	modules, err := r.GetAll()
+	if err != nil {
+		return nil, err
+	}
+
+	activos := make(map[int]bool, len(modules))
+	for _, module := range modules {
+		activos[module.ID] = true
+	}
+	hijos := make(map[int][]models.Module)
+	var raices []models.Module
+	for _, module := range modules {
+		if module.IdModuloPadre == nil || !activos[*module.IdModuloPadre] {
+			raices = append(raices, module)
+			continue
+		}
+		hijos[*module.IdModuloPadre] = append(hijos[*module.IdModuloPadre], module)
+	}
+
+	var construir func(module models.Module) models.ModuleResponse
+	construir = func(module models.Module) models.ModuleResponse {
+		node := models.ModuleResponse{
+			ID:                 module.ID,
+			IdModuloPadre:      module.IdModuloPadre,
+			Codigo:             module.Codigo,
+			Nombre:             module.Nombre,
+			Descripcion:        module.Descripcion,
+			FechaCreacion:      module.FechaCreacion,
+			FechaActualizacion: module.FechaActualizacion,
+		}
+		for _, hijo := range hijos[module.ID] {
+			node.Submodulos = append(node.Submodulos, construir(hijo))
+		}
+		return node
+	}
+
+	tree := make([]models.ModuleResponse, 0, len(raices))
+	for _, raiz := range raices {
+		tree = append(tree, construir(raiz))
+	}
+	return tree, nil
+}
+
 func (r *ModuleRepository) GetAll() ([]models.Module, error) {
 	var modules []models.Module
 	err := r.db.Where("fecha_eliminacion IS NULL").Find(&modules).Error
@@ -48,8 +179,9 @@ func (r *ModuleRepository) GetByID(id int) (*models.Module, error) {
 	return &module, nil
 }
 
+// Update guarda los cambios del módulo; el código es inmutable
 func (r *ModuleRepository) Update(module *models.Module) error {
-	return r.db.Save(module).Error
+	return r.db.Omit("codigo").Save(module).Error
 }
 
 func (r *ModuleRepository) Delete(id int) error {
@@ -63,56 +195,98 @@ func (r *ModuleRepository) Delete(id int) error {
 			return err
 		}
 
-		// Obtener la fecha actual
-		now := time.Now()
-
-		// Actualizar fecha_eliminacion del módulo
-		if err := tx.Model(&module).Update("fecha_eliminacion", now).Error; err != nil {
+		// El módulo se elimina junto con todos sus submódulos activos
+		descendantIDs, err := moduleDescendantIDs(tx, id)
+		if err != nil {
 			return err
 		}
+		ids := append([]int{id}, descendantIDs...)
 
-		// Marcar como eliminados los registros relacionados en rol_modulo_permisos
-		if err := tx.Model(&models.RolModuloPermiso{}).
-			Where("id_modulo = ?", id).
-			Update("fecha_eliminacion", now).Error; err != nil {
+		// Registrar el lote para restaurar luego solo lo que se marque aquí
+		lote := &models.LoteEliminacion{
+			Entidad:          models.EntidadModulo,
+			IdEntidad:        id,
+			FechaEliminacion: time.Now(),
+		}
+		if err := tx.Create(lote).Error; err != nil {
 			return err
 		}
+		marca := map[string]interface{}{
+			"fecha_eliminacion":   lote.FechaEliminacion,
+			"id_lote_eliminacion": lote.ID,
+		}
 
-		// Marcar como eliminados los registros relacionados en modulo_permisos
-		if err := tx.Model(&models.ModuloPermiso{}).
-			Where("id_modulo = ?", id).
-			Update("fecha_eliminacion", now).Error; err != nil {
+		// Actualizar fecha_eliminacion del módulo y sus submódulos
+		if err := tx.Model(&models.Module{}).
+			Where("id IN ? AND fecha_eliminacion IS NULL", ids).
+			Updates(marca).Error; err != nil {
 			return err
 		}
 
+		// Marcar como eliminados los registros relacionados en rol_modulo_permisos,
+		// modulo_permisos y recurso_permisos
+		for _, model := range []interface{}{&models.RolModuloPermiso{}, &models.ModuloPermiso{}, &models.RecursoPermiso{}} {
+			if err := tx.Model(model).
+				Where("id_modulo IN ? AND fecha_eliminacion IS NULL", ids).
+				Updates(marca).Error; err != nil {
+				return err
+			}
+		}
+
 		return nil
 	})
 }
 
 func (r *ModuleRepository) AssignPermissions(moduleID int, permisoTipoIDs []int) error {
 	return r.db.Transaction(func(tx *gorm.DB) error {
-		// Verificar que el módulo existe
+		// Verificar que el módulo existe y no está eliminado
 		var module models.Module
-		if err := tx.First(&module, moduleID).Error; err != nil {
+		if err := tx.Where("fecha_eliminacion IS NULL").First(&module, moduleID).Error; err != nil {
 			return fmt.Errorf("módulo no encontrado: %v", err)
 		}
 
 		// Verificar que todos los tipos de permisos existen
 		var count int64
-		if err := tx.Model(&models.PermisoTipo{}).Where("id IN ?", permisoTipoIDs).Count(&count).Error; err != nil {
+		if err := tx.Model(&models.PermisoTipo{}).Where("id IN ? AND fecha_eliminacion IS NULL", permisoTipoIDs).Count(&count).Error; err != nil {
 			return err
 		}
 		if int(count) != len(permisoTipoIDs) {
 			return fmt.Errorf("algunos tipos de permisos no existen")
 		}
 
-		// Eliminar permisos existentes
-		if err := tx.Where("id_modulo = ?", moduleID).Delete(&models.ModuloPermiso{}).Error; err != nil {
+		// Retirar los permisos que el módulo deja de ofrecer y las asignaciones
+		// de roles que los usan
+		var retirados []int
+		for _, model := range []interface{}{&models.ModuloPermiso{}, &models.RolModuloPermiso{}} {
+			var ids []int
+			if err := tx.Model(model).
+				Where("id_modulo = ? AND id_permiso_tipo NOT IN ? AND fecha_eliminacion IS NULL", moduleID, permisoTipoIDs).
+				Distinct().
+				Pluck("id_permiso_tipo", &ids).Error; err != nil {
+				return err
+			}
+			retirados = append(retirados, ids...)
+		}
+		if _, err := retirarPermisosModulo(tx, moduleID, retirados); err != nil {
 			return err
 		}
 
-		// Insertar nuevos permisos
+		// Insertar los permisos nuevos
+		var existentes []int
+		if err := tx.Model(&models.ModuloPermiso{}).
+			Where("id_modulo = ? AND fecha_eliminacion IS NULL", moduleID).
+			Pluck("id_permiso_tipo", &existentes).Error; err != nil {
+			return err
+		}
+		ofrecidos := make(map[int]bool, len(existentes))
+		for _, id := range existentes {
+			ofrecidos[id] = true
+		}
 		for _, permisoTipoID := range permisoTipoIDs {
+			if ofrecidos[permisoTipoID] {
+				continue
+			}
+			ofrecidos[permisoTipoID] = true
 			moduloPermiso := &models.ModuloPermiso{
 				IdModulo:      moduleID,
 				IdPermisoTipo: permisoTipoID,
@@ -122,32 +296,31 @@ func (r *ModuleRepository) AssignPermissions(moduleID int, permisoTipoIDs []int)
 			}
 		}
 
-		// Eliminar asignaciones de roles que ya no son válidas
-		var validPermisos []models.RolModuloPermiso
-		err := tx.Where("id_modulo = ? AND id_permiso_tipo NOT IN ?", moduleID, permisoTipoIDs).
-			Delete(&models.RolModuloPermiso{}).Error
-		if err != nil {
-			return err
-		}
-
-		return tx.Where("id_modulo = ?", moduleID).
-			Preload("Modulo").
-			Preload("PermisoTipo").
-			Find(&validPermisos).Error
+		return nil
 	})
 }
 
+// GetModuleWithPermissions devuelve el módulo con los tipos de permiso que
+// ofrece; los que dejó de ofrecer y los tipos retirados no se incluyen
 func (r *ModuleRepository) GetModuleWithPermissions(moduleID int) (*models.ModuleWithPermissions, error) {
 	var module models.Module
 	err := r.db.Where("fecha_eliminacion IS NULL").
-		Preload("Permisos").
 		First(&module, moduleID).Error
 	if err != nil {
 		return nil, err
 	}
 
+	if err := r.db.Joins("JOIN modulo_permisos ON modulo_permisos.id_permiso_tipo = permiso_tipos.id").
+		Where("modulo_permisos.id_modulo = ? AND modulo_permisos.fecha_eliminacion IS NULL", moduleID).
+		Where("permiso_tipos.fecha_eliminacion IS NULL").
+		Order("permiso_tipos.id").
+		Find(&module.Permisos).Error; err != nil {
+		return nil, err
+	}
+
 	return &models.ModuleWithPermissions{
 		ID:                 module.ID,
+		Codigo:             module.Codigo,
 		Nombre:             module.Nombre,
 		Descripcion:        module.Descripcion,
 		Permisos:           module.Permisos,
@@ -159,65 +332,226 @@ func (r *ModuleRepository) GetModuleWithPermissions(moduleID int) (*models.Modul
 
 func (r *ModuleRepository) RemovePermission(moduleID int, permisoTipoID int) error {
 	return r.db.Transaction(func(tx *gorm.DB) error {
-		// Primero eliminamos las asignaciones de roles que usan este permiso
-		if err := tx.Where(
-			"id_modulo = ? AND id_permiso_tipo = ?",
-			moduleID, permisoTipoID,
-		).Delete(&models.RolModuloPermiso{}).Error; err != nil {
-			return err
+		if err := tx.Where("fecha_eliminacion IS NULL").First(&models.Module{}, moduleID).Error; err != nil {
+			return fmt.Errorf("módulo no encontrado: %v", err)
 		}
 
-		// Luego eliminamos el permiso del módulo
-		result := tx.Where(
-			"id_modulo = ? AND id_permiso_tipo = ?",
-			moduleID, permisoTipoID,
-		).Delete(&models.ModuloPermiso{})
-
-		if result.Error != nil {
-			return result.Error
+		retirados, err := retirarPermisosModulo(tx, moduleID, []int{permisoTipoID})
+		if err != nil {
+			return err
 		}
-
-		if result.RowsAffected == 0 {
+		if retirados == 0 {
 			return fmt.Errorf("no se encontró el permiso especificado para este módulo")
 		}
-
 		return nil
 	})
 }
+
+// retirarPermisosModulo marca como eliminados, en un lote propio, los
+// permisos del módulo con los tipos indicados y las asignaciones de roles que
+// los usan. Las filas se conservan hasta la purga; la restauración del módulo
+// no las recupera porque no pertenecen a su lote. Devuelve cuántos permisos
+// del módulo se retiraron.
+func retirarPermisosModulo(tx *gorm.DB, moduleID int, permisoTipoIDs []int) (int64, error) {
+	if len(permisoTipoIDs) == 0 {
+		return 0, nil
+	}
+
+	lote := &models.LoteEliminacion{
+		Entidad:          models.EntidadModuloPermiso,
+		IdEntidad:        moduleID,
+		FechaEliminacion: time.Now(),
+	}
+	if err := tx.Create(lote).Error; err != nil {
+		return 0, err
+	}
+	marca := map[string]interface{}{
+		"fecha_eliminacion":   lote.FechaEliminacion,
+		"id_lote_eliminacion": lote.ID,
+	}
+
+	if err := tx.Model(&models.RolModuloPermiso{}).
+		Where("id_modulo = ? AND id_permiso_tipo IN ? AND fecha_eliminacion IS NULL", moduleID, permisoTipoIDs).
+		Updates(marca).Error; err != nil {
+		return 0, err
+	}
+
+	result := tx.Model(&models.ModuloPermiso{}).
+		Where("id_modulo = ? AND id_permiso_tipo IN ? AND fecha_eliminacion IS NULL", moduleID, permisoTipoIDs).
+		Updates(marca)
+	return result.RowsAffected, result.Error
+}
+
+// Restore restaura un módulo eliminado junto con los submódulos y registros
+// relacionados que se eliminaron en la misma operación. Lo que ya estaba
+// eliminado antes no se restaura.
 func (r *ModuleRepository) Restore(id int) error {
 	return r.db.Transaction(func(tx *gorm.DB) error {
-		// Verificar si el módulo existe y está eliminado
-		var module models.Module
-		if err := tx.Unscoped().First(&module, id).Error; err != nil {
-			return fmt.Errorf("módulo no encontrado")
-		}
-
-		if module.FechaEliminacion == nil {
-			return fmt.Errorf("el módulo no está eliminado")
+		_, ids, enLote, err := moduleRestoreSet(tx, id)
+		if err != nil {
+			return err
 		}
 
-		// Restaurar el módulo
-		if err := tx.Model(&module).Update("fecha_eliminacion", nil).Error; err != nil {
-			return err
+		restaurar := map[string]interface{}{
+			"fecha_eliminacion":   nil,
+			"id_lote_eliminacion": nil,
 		}
 
-		// Restaurar registros relacionados en rol_modulo_permisos
-		if err := tx.Model(&models.RolModuloPermiso{}).
-			Where("id_modulo = ?", id).
-			Update("fecha_eliminacion", nil).Error; err != nil {
+		// Restaurar el módulo y sus submódulos
+		if err := tx.Model(&models.Module{}).
+			Where("id IN ?", ids).
+			Updates(restaurar).Error; err != nil {
 			return err
 		}
 
-		// Restaurar registros relacionados en modulo_permisos
-		if err := tx.Model(&models.ModuloPermiso{}).
-			Where("id_modulo = ?", id).
-			Update("fecha_eliminacion", nil).Error; err != nil {
-			return err
+		// Restaurar los registros relacionados del mismo lote
+		for _, model := range []interface{}{&models.RolModuloPermiso{}, &models.ModuloPermiso{}, &models.RecursoPermiso{}} {
+			if err := tx.Model(model).Scopes(enLote).Updates(restaurar).Error; err != nil {
+				return err
+			}
 		}
 
 		return nil
 	})
 }
+
+// PreviewRestore describe lo que restauraría Restore sin modificar nada
+func (r *ModuleRepository) PreviewRestore(id int) (*models.RestauracionModuloPreview, error) {
+	module, ids, enLote, err := moduleRestoreSet(r.db, id)
+	if err != nil {
+		return nil, err
+	}
+
+	preview := &models.RestauracionModuloPreview{
+		IdLoteEliminacion: module.IdLoteEliminacion,
+		FechaEliminacion:  *module.FechaEliminacion,
+		Modulos:           make([]models.ModuleResponse, 0, len(ids)),
+		PermisosModulo:    make([]models.PermisoRestaurable, 0),
+		AsignacionesRol:   make([]models.PermisoRestaurable, 0),
+	}
+
+	var modules []models.Module
+	if err := r.db.Where("id IN ?", ids).Order("id").Find(&modules).Error; err != nil {
+		return nil, err
+	}
+	for _, m := range modules {
+		preview.Modulos = append(preview.Modulos, models.ModuleResponse{
+			ID:                 m.ID,
+			IdModuloPadre:      m.IdModuloPadre,
+			Codigo:             m.Codigo,
+			Nombre:             m.Nombre,
+			Descripcion:        m.Descripcion,
+			FechaCreacion:      m.FechaCreacion,
+			FechaActualizacion: m.FechaActualizacion,
+			FechaEliminacion:   m.FechaEliminacion,
+		})
+	}
+
+	var moduloPermisos []models.ModuloPermiso
+	if err := r.db.Scopes(enLote).Preload("PermisoTipo").Order("id").Find(&moduloPermisos).Error; err != nil {
+		return nil, err
+	}
+	for _, mp := range moduloPermisos {
+		permisoTipoID := mp.IdPermisoTipo
+		preview.PermisosModulo = append(preview.PermisosModulo, models.PermisoRestaurable{
+			ID:            mp.ID,
+			IdModulo:      mp.IdModulo,
+			IdPermisoTipo: &permisoTipoID,
+			Codigo:        mp.PermisoTipo.Codigo,
+		})
+	}
+
+	var grants []models.RolModuloPermiso
+	if err := r.db.Scopes(enLote).Preload("Role").Preload("PermisoTipo").Order("id").Find(&grants).Error; err != nil {
+		return nil, err
+	}
+	for _, grant := range grants {
+		restaurable := models.PermisoRestaurable{
+			ID:            grant.ID,
+			IdRol:         grant.IdRol,
+			Rol:           grant.Role.Nombre,
+			IdModulo:      *grant.IdModulo,
+			IdPermisoTipo: grant.IdPermisoTipo,
+		}
+		if grant.PermisoTipo != nil {
+			restaurable.Codigo = grant.PermisoTipo.Codigo
+		}
+		preview.AsignacionesRol = append(preview.AsignacionesRol, restaurable)
+	}
+
+	if err := r.db.Model(&models.RecursoPermiso{}).Scopes(enLote).Count(&preview.PermisosRecurso).Error; err != nil {
+		return nil, err
+	}
+
+	// Registros de estos módulos eliminados antes y que seguirán eliminados
+	var eliminados int64
+	for _, model := range []interface{}{&models.RolModuloPermiso{}, &models.ModuloPermiso{}, &models.RecursoPermiso{}} {
+		var total int64
+		if err := r.db.Model(model).
+			Where("id_modulo IN ? AND fecha_eliminacion IS NOT NULL", ids).
+			Count(&total).Error; err != nil {
+			return nil, err
+		}
+		eliminados += total
+	}
+	restaurables := int64(len(preview.PermisosModulo)+len(preview.AsignacionesRol)) + preview.PermisosRecurso
+	preview.NoRestaurados = eliminados - restaurables
+
+	return preview, nil
+}
+
+// moduleRestoreSet valida que el módulo se pueda restaurar y devuelve el
+// módulo, los ids de los módulos que se restaurarían con él y un filtro para
+// los registros relacionados eliminados en la misma operación. Las
+// eliminaciones anteriores a los lotes se reconocen por la fecha de eliminación.
+func moduleRestoreSet(db *gorm.DB, id int) (*models.Module, []int, func(*gorm.DB) *gorm.DB, error) {
+	// Verificar si el módulo existe y está eliminado
+	var module models.Module
+	if err := db.First(&module, id).Error; err != nil {
+		return nil, nil, nil, fmt.Errorf("módulo no encontrado")
+	}
+
+	if module.FechaEliminacion == nil {
+		return nil, nil, nil, fmt.Errorf("el módulo no está eliminado")
+	}
+
+	// Un submódulo no puede restaurarse bajo un padre eliminado
+	if module.IdModuloPadre != nil {
+		var parent models.Module
+		if err := db.First(&parent, *module.IdModuloPadre).Error; err == nil && parent.FechaEliminacion != nil {
+			return nil, nil, nil, fmt.Errorf("el módulo padre %d está eliminado; restáurelo primero", parent.ID)
+		}
+	}
+
+	// Se restauran los submódulos que se eliminaron junto con el módulo
+	eliminadoEn := *module.FechaEliminacion
+	ids := []int{id}
+	pending := []int{id}
+	for len(pending) > 0 {
+		query := db.Model(&models.Module{}).Where("id_modulo_padre IN ?", pending)
+		if module.IdLoteEliminacion != nil {
+			query = query.Where("id_lote_eliminacion = ?", *module.IdLoteEliminacion)
+		} else {
+			query = query.Where("fecha_eliminacion = ?", eliminadoEn)
+		}
+		var childIDs []int
+		if err := query.Pluck("id", &childIDs).Error; err != nil {
+			return nil, nil, nil, err
+		}
+		ids = append(ids, childIDs...)
+		pending = childIDs
+	}
+
+	enLote := func(q *gorm.DB) *gorm.DB {
+		if module.IdLoteEliminacion != nil {
+			return q.Where("id_lote_eliminacion = ?", *module.IdLoteEliminacion)
+		}
+		return q.Where("id_modulo IN ? AND fecha_eliminacion = ? AND id_lote_eliminacion IS NULL", ids, eliminadoEn)
+	}
+
+	return &module, ids, enLote, nil
+}
+
 func (r *ModuleRepository) GetDeletedModules() ([]models.Module, error) {
 	var modules []models.Module
 	err := r.db.Where("fecha_eliminacion IS NOT NULL").
@@ -225,3 +559,102 @@ func (r *ModuleRepository) GetDeletedModules() ([]models.Module, error) {
 		Find(&modules).Error
 	return modules, err
 }
+
+// moduleDescendantIDs devuelve todos los submódulos activos (directos e
+// indirectos) de un módulo
+func moduleDescendantIDs(db *gorm.DB, moduleID int) ([]int, error) {
+	visited := map[int]bool{moduleID: true}
+	descendants := make([]int, 0)
+	pending := []int{moduleID}
+
+	for len(pending) > 0 {
+		var childIDs []int
+		if err := db.Model(&models.Module{}).
+			Where("id_modulo_padre IN ? AND fecha_eliminacion IS NULL", pending).
+			Pluck("id", &childIDs).Error; err != nil {
+			return nil, err
+		}
+
+		pending = pending[:0]
+		for _, id := range childIDs {
+			if visited[id] {
+				continue
+			}
+			visited[id] = true
+			descendants = append(descendants, id)
+			pending = append(pending, id)
+		}
+	}
+
+	return descendants, nil
+}
+
+// moduleAncestorIDs devuelve los ancestros activos de un módulo, del padre
+// directo a la raíz
+func moduleAncestorIDs(db *gorm.DB, moduleID int) ([]int, error) {
+	visited := map[int]bool{moduleID: true}
+	ancestors := make([]int, 0)
+	current := moduleID
+
+	for {
+		var module models.Module
+		if err := db.Where("fecha_eliminacion IS NULL").First(&module, current).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return ancestors, nil
+			}
+			return nil, err
+		}
+		if current != moduleID {
+			ancestors = append(ancestors, current)
+		}
+		if module.IdModuloPadre == nil || visited[*module.IdModuloPadre] {
+			return ancestors, nil
+		}
+		visited[*module.IdModuloPadre] = true
+		current = *module.IdModuloPadre
+	}
+}
+
+// resolveModuleID devuelve el id de un módulo activo a partir de su id o de
+// su código. Si se indican ambos deben referirse al mismo módulo.
+func resolveModuleID(db *gorm.DB, id int, codigo string) (int, error) {
+	if codigo == "" {
+		if id == 0 {
+			return 0, fmt.Errorf("debe indicar modulo_id o modulo_codigo")
+		}
+		return id, nil
+	}
+
+	var module models.Module
+	if err := db.Where("codigo = ? AND fecha_eliminacion IS NULL", strings.ToLower(strings.TrimSpace(codigo))).
+		First(&module).Error; err != nil {
+		return 0, fmt.Errorf("módulo %s no encontrado", codigo)
+	}
+	if id != 0 && id != module.ID {
+		return 0, fmt.Errorf("modulo_id %d no corresponde al código %s", id, codigo)
+	}
+	return module.ID, nil
+}
+
+// codigoModuloDisponible devuelve el código base, o el primero libre con un
+// sufijo numérico si ya está en uso
+func codigoModuloDisponible(db *gorm.DB, base string) (string, error) {
+	if base == "" {
+		base = "modulo"
+	}
+
+	codigo := base
+	for i := 2; ; i++ {
+		var exists bool
+		if err := db.Model(&models.Module{}).
+			Where("codigo = ?", codigo).
+			Select("count(*) > 0").
+			Scan(&exists).Error; err != nil {
+			return "", err
+		}
+		if !exists {
+			return codigo, nil
+		}
+		codigo = models.CodigoConSufijo(base, fmt.Sprintf("-%d", i))
+	}
+}
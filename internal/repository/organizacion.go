@@ -0,0 +1,110 @@
+package repository
+
+import (
+	"context"
+	"reflect"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Los modelos con el campo IdOrganizacion (usuarios, roles, módulos, sedes,
+// asignaciones de permisos, grupos, delegaciones, elevaciones, restricciones
+// SoD y plantillas, junto con sus filas dependientes) pertenecen a una
+// organización. Cuando el contexto
+// de la consulta indica una organización, los callbacks registrados por
+// RegisterOrganizacionScope limitan consultas, actualizaciones y eliminaciones
+// a sus filas y asignan la organización a las filas creadas, de modo que
+// ningún repositorio devuelve ni modifica filas de otra organización. Sin
+// organización en el contexto (tareas programadas, línea de comandos) las
+// operaciones abarcan todas las organizaciones.
+
+type claveOrganizacion struct{}
+
+// ConOrganizacion devuelve un contexto cuyas consultas quedan limitadas a la organización
+func ConOrganizacion(ctx context.Context, id int) context.Context {
+	return context.WithValue(ctx, claveOrganizacion{}, id)
+}
+
+// OrganizacionDe devuelve la organización del contexto, si la tiene
+func OrganizacionDe(ctx context.Context) (int, bool) {
+	if ctx == nil {
+		return 0, false
+	}
+	id, ok := ctx.Value(claveOrganizacion{}).(int)
+	return id, ok
+}
+
+// RegisterOrganizacionScope registra los callbacks que aíslan los datos de cada organización
+func RegisterOrganizacionScope(db *gorm.DB) error {
+	callbacks := db.Callback()
+	if err := callbacks.Query().Before("gorm:query").Register("organizacion:query", filtrarPorOrganizacion); err != nil {
+		return err
+	}
+	if err := callbacks.Row().Before("gorm:row").Register("organizacion:row", filtrarPorOrganizacion); err != nil {
+		return err
+	}
+	if err := callbacks.Update().Before("gorm:update").Register("organizacion:update", filtrarModificacionPorOrganizacion); err != nil {
+		return err
+	}
+	if err := callbacks.Delete().Before("gorm:delete").Register("organizacion:delete", filtrarModificacionPorOrganizacion); err != nil {
+		return err
+	}
+	return callbacks.Create().Before("gorm:create").Register("organizacion:create", asignarOrganizacion)
+}
+
+// organizacionAplicable devuelve la organización del contexto si el modelo de
+// la sentencia pertenece a una organización
+func organizacionAplicable(db *gorm.DB) (int, bool) {
+	id, ok := OrganizacionDe(db.Statement.Context)
+	if !ok || db.Statement.Schema == nil || db.Statement.Schema.LookUpField("IdOrganizacion") == nil {
+		return 0, false
+	}
+	return id, true
+}
+
+func filtrarPorOrganizacion(db *gorm.DB) {
+	id, ok := organizacionAplicable(db)
+	if !ok {
+		return
+	}
+	db.Statement.AddClause(clause.Where{Exprs: []clause.Expression{
+		clause.Eq{Column: clause.Column{Table: clause.CurrentTable, Name: "id_organizacion"}, Value: id},
+	}})
+}
+
+// filtrarModificacionPorOrganizacion limita las actualizaciones y eliminaciones
+// a la organización, salvo las que gorm rechazará por no tener condiciones ni
+// clave primaria: agregar la organización las volvería válidas y afectarían a
+// todas sus filas
+func filtrarModificacionPorOrganizacion(db *gorm.DB) {
+	if _, ok := organizacionAplicable(db); !ok || sinCondiciones(db.Statement) {
+		return
+	}
+	filtrarPorOrganizacion(db)
+}
+
+func sinCondiciones(stmt *gorm.Statement) bool {
+	if _, ok := stmt.Clauses["WHERE"]; ok {
+		return false
+	}
+	switch stmt.ReflectValue.Kind() {
+	case reflect.Struct:
+		if stmt.Schema.PrioritizedPrimaryField == nil {
+			return true
+		}
+		_, zero := stmt.Schema.PrioritizedPrimaryField.ValueOf(stmt.Context, stmt.ReflectValue)
+		return zero
+	case reflect.Slice, reflect.Array:
+		return stmt.ReflectValue.Len() == 0
+	}
+	return true
+}
+
+func asignarOrganizacion(db *gorm.DB) {
+	id, ok := organizacionAplicable(db)
+	if !ok {
+		return
+	}
+	db.Statement.SetColumn("IdOrganizacion", id, true)
+}
@@ -0,0 +1,89 @@
+package repository
+
+import (
+	"auth-service/internal/models"
+	"fmt"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type OrganizacionRepository struct {
+	db *gorm.DB
+}
+
+func NewOrganizacionRepository(db *gorm.DB) *OrganizacionRepository {
+	return &OrganizacionRepository{db: db}
+}
+
+func (r *OrganizacionRepository) Create(organizacion *models.Organizacion) error {
+	organizacion.Codigo = strings.ToLower(strings.TrimSpace(organizacion.Codigo))
+	if !models.CodigoOrganizacionValido(organizacion.Codigo) {
+		return fmt.Errorf("código de organización inválido: use hasta %d minúsculas, dígitos, guiones o guiones bajos",
+			models.MaxLongitudCodigoModulo)
+	}
+
+	var exists bool
+	if err := r.db.Model(&models.Organizacion{}).
+		Where("codigo = ?", organizacion.Codigo).
+		Select("count(*) > 0").
+		Scan(&exists).Error; err != nil {
+		return err
+	}
+	if exists {
+		return fmt.Errorf("ya existe una organización con este código")
+	}
+
+	organizacion.Activa = true
+	organizacion.FechaCreacion = time.Now()
+	organizacion.FechaActualizacion = time.Now()
+	return r.db.Create(organizacion).Error
+}
+
+func (r *OrganizacionRepository) GetAll() ([]models.Organizacion, error) {
+	var organizaciones []models.Organizacion
+	err := r.db.Order("id").Find(&organizaciones).Error
+	return organizaciones, err
+}
+
+func (r *OrganizacionRepository) GetByID(id int) (*models.Organizacion, error) {
+	var organizacion models.Organizacion
+	if err := r.db.First(&organizacion, id).Error; err != nil {
+		return nil, err
+	}
+	return &organizacion, nil
+}
+
+func (r *OrganizacionRepository) GetByCodigo(codigo string) (*models.Organizacion, error) {
+	var organizacion models.Organizacion
+	if err := r.db.Where("codigo = ?", strings.ToLower(strings.TrimSpace(codigo))).First(&organizacion).Error; err != nil {
+		return nil, err
+	}
+	return &organizacion, nil
+}
+
+// Update cambia el nombre y el estado de la organización; el código no cambia.
+// La organización por defecto no puede desactivarse.
+func (r *OrganizacionRepository) Update(id int, req models.UpdateOrganizacionRequest) (*models.Organizacion, error) {
+	organizacion, err := r.GetByID(id)
+	if err != nil {
+		return nil, fmt.Errorf("organización no encontrada: %v", err)
+	}
+
+	cambios := map[string]interface{}{
+		"nombre":              req.Nombre,
+		"fecha_actualizacion": time.Now(),
+	}
+	if req.Activa != nil {
+		if !*req.Activa && organizacion.ID == models.OrganizacionPorDefecto {
+			return nil, fmt.Errorf("la organización por defecto no puede desactivarse")
+		}
+		cambios["activa"] = *req.Activa
+	}
+
+	if err := r.db.Model(organizacion).Updates(cambios).Error; err != nil {
+		return nil, err
+	}
+	return r.GetByID(id)
+}
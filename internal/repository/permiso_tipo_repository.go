@@ -3,11 +3,36 @@ package repository
 import (
 	"auth-service/internal/models"
 	"fmt"
+	"log"
+	"regexp"
+	"sort"
 	"strings"
+	"time"
 
 	"gorm.io/gorm"
 )
 
+// codigoPermisoValido admite letras mayúsculas, dígitos y guiones bajos,
+// empezando por una letra
+var codigoPermisoValido = regexp.MustCompile(`^[A-Z][A-Z0-9_]*$`)
+
+// PermisoTipoEnUsoError indica que un tipo de permiso no se puede retirar
+// porque aún lo usan otros registros
+type PermisoTipoEnUsoError struct {
+	IdPermisoTipo int
+	Codigo        string
+	Uso           map[string]int64 // registros activos por tabla
+}
+
+func (e *PermisoTipoEnUsoError) Error() string {
+	tablas := make([]string, 0, len(e.Uso))
+	for tabla, total := range e.Uso {
+		tablas = append(tablas, fmt.Sprintf("%s: %d", tabla, total))
+	}
+	sort.Strings(tablas)
+	return fmt.Sprintf("el tipo de permiso %s está en uso (%s)", e.Codigo, strings.Join(tablas, ", "))
+}
+
 type PermisoTipoRepository struct {
 	db *gorm.DB
 }
@@ -17,25 +42,23 @@ func NewPermisoTipoRepository(db *gorm.DB) *PermisoTipoRepository {
 }
 
 func (r *PermisoTipoRepository) Create(permisoTipo *models.PermisoTipo) error {
-	validCodigos := []string{
-		models.PermisoVer,
-		models.PermisoCreateEdit,
-		models.PermisoExportar,
-		models.PermisoEliminar,
+	codigo := strings.ToUpper(strings.TrimSpace(permisoTipo.Codigo))
+	if len(codigo) > models.MaxLongitudCodigoPermiso || !codigoPermisoValido.MatchString(codigo) {
+		return fmt.Errorf("código de permiso inválido: use hasta %d letras, dígitos o guiones bajos, empezando por una letra",
+			models.MaxLongitudCodigoPermiso)
 	}
+	permisoTipo.Codigo = codigo
 
-	codigoUpper := strings.ToUpper(permisoTipo.Codigo)
-	isValid := false
-	for _, codigo := range validCodigos {
-		if codigoUpper == codigo {
-			permisoTipo.Codigo = codigo
-			isValid = true
-			break
+	// Los códigos retirados siguen reservados para no cambiar el significado
+	// de registros históricos
+	var existing models.PermisoTipo
+	if err := r.db.Where("codigo = ?", codigo).First(&existing).Error; err == nil {
+		if existing.FechaEliminacion != nil {
+			return fmt.Errorf("el código %s pertenece a un tipo de permiso retirado", codigo)
 		}
-	}
-
-	if !isValid {
-		return fmt.Errorf("código de permiso inválido")
+		return fmt.Errorf("ya existe un tipo de permiso con el código %s", codigo)
+	} else if err != gorm.ErrRecordNotFound {
+		return err
 	}
 
 	return r.db.Create(permisoTipo).Error
@@ -43,13 +66,13 @@ func (r *PermisoTipoRepository) Create(permisoTipo *models.PermisoTipo) error {
 
 func (r *PermisoTipoRepository) GetAll() ([]models.PermisoTipo, error) {
 	var permisoTipos []models.PermisoTipo
-	err := r.db.Find(&permisoTipos).Error
+	err := r.db.Where("fecha_eliminacion IS NULL").Find(&permisoTipos).Error
 	return permisoTipos, err
 }
 
 func (r *PermisoTipoRepository) GetByID(id int) (*models.PermisoTipo, error) {
 	var permisoTipo models.PermisoTipo
-	err := r.db.First(&permisoTipo, id).Error
+	err := r.db.Where("fecha_eliminacion IS NULL").First(&permisoTipo, id).Error
 	if err != nil {
 		return nil, err
 	}
@@ -58,17 +81,130 @@ func (r *PermisoTipoRepository) GetByID(id int) (*models.PermisoTipo, error) {
 
 func (r *PermisoTipoRepository) GetByCodigo(codigo string) (*models.PermisoTipo, error) {
 	var permisoTipo models.PermisoTipo
-	err := r.db.Where("codigo = ?", codigo).First(&permisoTipo).Error
+	err := r.db.Where("codigo = ? AND fecha_eliminacion IS NULL", strings.ToUpper(codigo)).First(&permisoTipo).Error
 	if err != nil {
 		return nil, err
 	}
 	return &permisoTipo, nil
 }
 
+// Update guarda el nombre y la descripción del tipo de permiso; el código no cambia
 func (r *PermisoTipoRepository) Update(permisoTipo *models.PermisoTipo) error {
-	return r.db.Save(permisoTipo).Error
+	result := r.db.Model(&models.PermisoTipo{}).
+		Where("id = ? AND fecha_eliminacion IS NULL", permisoTipo.ID).
+		Updates(map[string]interface{}{
+			"nombre":      permisoTipo.Nombre,
+			"descripcion": permisoTipo.Descripcion,
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("tipo de permiso no encontrado")
+	}
+	return nil
+}
+
+// Delete retira el tipo de permiso. Si aún está en uso devuelve un
+// *PermisoTipoEnUsoError, salvo que cascade sea true: en ese caso también se
+// retiran las asignaciones que lo usan y se cierran sus elevaciones.
+func (r *PermisoTipoRepository) Delete(id int, cascade bool) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var permisoTipo models.PermisoTipo
+		if err := tx.Where("fecha_eliminacion IS NULL").First(&permisoTipo, id).Error; err != nil {
+			return fmt.Errorf("tipo de permiso no encontrado: %v", err)
+		}
+
+		uso, err := permisoTipoUso(tx, id)
+		if err != nil {
+			return err
+		}
+		if len(uso) > 0 && !cascade {
+			return &PermisoTipoEnUsoError{IdPermisoTipo: id, Codigo: permisoTipo.Codigo, Uso: uso}
+		}
+
+		now := time.Now()
+		if len(uso) > 0 {
+			// Un mismo lote agrupa todos los registros retirados en cascada
+			lote := &models.LoteEliminacion{
+				Entidad:          models.EntidadPermisoTipo,
+				IdEntidad:        id,
+				FechaEliminacion: now,
+			}
+			if err := tx.Create(lote).Error; err != nil {
+				return err
+			}
+			if err := retirePermisoTipoUso(tx, id, lote); err != nil {
+				return err
+			}
+			log.Printf("Tipo de permiso %s retirado en cascada (lote %d): %v", permisoTipo.Codigo, lote.ID, uso)
+		}
+
+		return tx.Model(&permisoTipo).Update("fecha_eliminacion", now).Error
+	})
+}
+
+// permisoTipoUso cuenta los registros activos que usan el tipo de permiso
+func permisoTipoUso(db *gorm.DB, id int) (map[string]int64, error) {
+	consultas := map[string]*gorm.DB{
+		"modulo_permisos":        db.Model(&models.ModuloPermiso{}).Where("fecha_eliminacion IS NULL"),
+		"rol_modulo_permisos":    db.Model(&models.RolModuloPermiso{}).Where("fecha_eliminacion IS NULL"),
+		"grupo_modulo_permisos":  db.Model(&models.GrupoModuloPermiso{}).Where("fecha_eliminacion IS NULL"),
+		"recurso_permisos":       db.Model(&models.RecursoPermiso{}).Where("fecha_eliminacion IS NULL"),
+		"delegacion_permisos":    db.Model(&models.DelegacionPermiso{}).Where("fecha_eliminacion IS NULL"),
+		"plantilla_rol_permisos": db.Model(&models.PlantillaRolPermiso{}).Where("fecha_eliminacion IS NULL"),
+		"solicitudes_elevacion": db.Model(&models.SolicitudElevacion{}).
+			Where("estado = ? OR (estado = ? AND fecha_expiracion > ?)",
+				models.ElevacionPendiente, models.ElevacionAprobada, time.Now()),
+	}
+
+	uso := make(map[string]int64)
+	for tabla, consulta := range consultas {
+		var count int64
+		if err := consulta.Where("id_permiso_tipo = ?", id).Count(&count).Error; err != nil {
+			return nil, err
+		}
+		if count > 0 {
+			uso[tabla] = count
+		}
+	}
+	return uso, nil
 }
 
-func (r *PermisoTipoRepository) Delete(id int) error {
-	return r.db.Delete(&models.PermisoTipo{}, id).Error
+// retirePermisoTipoUso retira los registros que usan el tipo de permiso: los
+// marca como eliminados en el lote indicado y rechaza o expira las elevaciones
+// pendientes o activas
+func retirePermisoTipoUso(tx *gorm.DB, id int, lote *models.LoteEliminacion) error {
+	now := lote.FechaEliminacion
+	marca := map[string]interface{}{
+		"fecha_eliminacion":   now,
+		"id_lote_eliminacion": lote.ID,
+	}
+	for _, model := range []interface{}{
+		&models.ModuloPermiso{}, &models.RolModuloPermiso{}, &models.RecursoPermiso{},
+		&models.GrupoModuloPermiso{}, &models.DelegacionPermiso{}, &models.PlantillaRolPermiso{},
+	} {
+		if err := tx.Model(model).
+			Where("id_permiso_tipo = ? AND fecha_eliminacion IS NULL", id).
+			Updates(marca).Error; err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Model(&models.SolicitudElevacion{}).
+		Where("id_permiso_tipo = ? AND estado = ?", id, models.ElevacionPendiente).
+		Updates(map[string]interface{}{
+			"estado":          models.ElevacionRechazada,
+			"motivo_decision": "tipo de permiso retirado",
+			"fecha_decision":  now,
+		}).Error; err != nil {
+		return err
+	}
+
+	return tx.Model(&models.SolicitudElevacion{}).
+		Where("id_permiso_tipo = ? AND estado = ? AND fecha_expiracion > ?", id, models.ElevacionAprobada, now).
+		Updates(map[string]interface{}{
+			"estado":           models.ElevacionExpirada,
+			"fecha_expiracion": now,
+		}).Error
 }
@@ -0,0 +1,64 @@
+package repository
+
+import (
+	"auth-service/internal/models"
+	"fmt"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// PermisosNoHabilitadosError indica que se intentó asignar tipos de permiso
+// que el módulo no ofrece en modulo_permisos
+type PermisosNoHabilitadosError struct {
+	IdModulo int
+	Codigos  []string
+}
+
+func (e *PermisosNoHabilitadosError) Error() string {
+	return fmt.Sprintf("el módulo %d no ofrece los permisos: %s", e.IdModulo, strings.Join(e.Codigos, ", "))
+}
+
+// validatePermisosHabilitados comprueba que el módulo ofrezca todos los tipos
+// de permiso indicados y devuelve un *PermisosNoHabilitadosError con los que no
+func validatePermisosHabilitados(db *gorm.DB, moduleID int, permisoTipoIDs []int) error {
+	if len(permisoTipoIDs) == 0 {
+		return nil
+	}
+
+	var faltantes []models.PermisoTipo
+	if err := db.Where("id IN ?", permisoTipoIDs).
+		Where("id NOT IN (?)", db.Model(&models.ModuloPermiso{}).
+			Where("id_modulo = ? AND fecha_eliminacion IS NULL", moduleID).
+			Select("id_permiso_tipo")).
+		Order("codigo").
+		Find(&faltantes).Error; err != nil {
+		return err
+	}
+	if len(faltantes) == 0 {
+		return nil
+	}
+
+	codigos := make([]string, len(faltantes))
+	for i, pt := range faltantes {
+		codigos[i] = pt.Codigo
+	}
+	return &PermisosNoHabilitadosError{IdModulo: moduleID, Codigos: codigos}
+}
+
+// permisosHabilitados devuelve, por módulo, los tipos de permiso que ofrece
+func permisosHabilitados(db *gorm.DB) (map[int]map[int]bool, error) {
+	var moduloPermisos []models.ModuloPermiso
+	if err := db.Where("fecha_eliminacion IS NULL").Find(&moduloPermisos).Error; err != nil {
+		return nil, err
+	}
+
+	habilitados := make(map[int]map[int]bool)
+	for _, mp := range moduloPermisos {
+		if habilitados[mp.IdModulo] == nil {
+			habilitados[mp.IdModulo] = make(map[int]bool)
+		}
+		habilitados[mp.IdModulo][mp.IdPermisoTipo] = true
+	}
+	return habilitados, nil
+}
@@ -0,0 +1,194 @@
+package repository
+
+import (
+	"auth-service/internal/models"
+	"context"
+	"fmt"
+
+	"gorm.io/gorm"
+)
+
+type PlantillaRolRepository struct {
+	db *gorm.DB
+}
+
+func NewPlantillaRolRepository(db *gorm.DB) *PlantillaRolRepository {
+	return &PlantillaRolRepository{db: db}
+}
+
+// WithContext devuelve una copia del repositorio cuyas consultas usan el
+// contexto indicado, incluida la organización de la petición
+func (r *PlantillaRolRepository) WithContext(ctx context.Context) *PlantillaRolRepository {
+	return &PlantillaRolRepository{db: r.db.WithContext(ctx)}
+}
+
+func (r *PlantillaRolRepository) Create(plantilla *models.PlantillaRol) error {
+	var exists bool
+	if err := r.db.Model(&models.PlantillaRol{}).
+		Where("LOWER(nombre) = LOWER(?)", plantilla.Nombre).
+		Select("count(*) > 0").
+		Scan(&exists).Error; err != nil {
+		return err
+	}
+
+	if exists {
+		return fmt.Errorf("ya existe una plantilla con este nombre")
+	}
+
+	if err := validatePermisosPlantilla(r.db, plantilla.Permisos); err != nil {
+		return err
+	}
+
+	return r.db.Create(plantilla).Error
+}
+
+func (r *PlantillaRolRepository) GetAll() ([]models.PlantillaRol, error) {
+	var plantillas []models.PlantillaRol
+	err := r.db.Preload("Permisos", "fecha_eliminacion IS NULL").Find(&plantillas).Error
+	return plantillas, err
+}
+
+func (r *PlantillaRolRepository) GetByID(id int) (*models.PlantillaRol, error) {
+	var plantilla models.PlantillaRol
+	err := r.db.Preload("Permisos", "fecha_eliminacion IS NULL").
+		Preload("Permisos.Modulo").
+		Preload("Permisos.PermisoTipo").
+		First(&plantilla, id).Error
+	if err != nil {
+		return nil, fmt.Errorf("plantilla no encontrada: %v", err)
+	}
+	return &plantilla, nil
+}
+
+func (r *PlantillaRolRepository) Delete(id int) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("id_plantilla = ?", id).Delete(&models.PlantillaRolPermiso{}).Error; err != nil {
+			return err
+		}
+
+		result := tx.Delete(&models.PlantillaRol{}, id)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return fmt.Errorf("plantilla no encontrada")
+		}
+		return nil
+	})
+}
+
+// Instantiate crea un rol con los permisos de la plantilla, aplicando antes
+// los permisos agregados o reemplazados y las exclusiones de la solicitud
+func (r *PlantillaRolRepository) Instantiate(plantillaID int, req models.InstantiatePlantillaRolRequest) (*models.Role, error) {
+	plantilla, err := r.GetByID(plantillaID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Entradas de la plantilla indexadas por módulo y tipo de permiso,
+	// conservando el orden original
+	permisos := make(map[[2]int]models.PlantillaRolPermiso)
+	var orden [][2]int
+	agregar := func(p models.PlantillaRolPermiso) {
+		objetivo := p.Objetivo()
+		if _, exists := permisos[objetivo]; !exists {
+			orden = append(orden, objetivo)
+		}
+		permisos[objetivo] = p
+	}
+	for _, p := range plantilla.Permisos {
+		agregar(p)
+	}
+	for _, p := range req.Permisos {
+		agregar(p.ToPermiso())
+	}
+	for _, p := range req.Excluir {
+		excluido := p.ToPermiso()
+		delete(permisos, excluido.Objetivo())
+	}
+
+	resultado := make([]models.PlantillaRolPermiso, 0, len(permisos))
+	for _, objetivo := range orden {
+		if p, exists := permisos[objetivo]; exists {
+			resultado = append(resultado, p)
+		}
+	}
+	if err := validatePermisosPlantilla(r.db, resultado); err != nil {
+		return nil, err
+	}
+
+	role := &models.Role{
+		Nombre:      req.Nombre,
+		Descripcion: req.Descripcion,
+	}
+
+	err = r.db.Transaction(func(tx *gorm.DB) error {
+		var exists bool
+		if err := tx.Model(&models.Role{}).
+			Where("LOWER(nombre) = LOWER(?)", role.Nombre).
+			Select("count(*) > 0").
+			Scan(&exists).Error; err != nil {
+			return err
+		}
+		if exists {
+			return fmt.Errorf("ya existe un rol con este nombre")
+		}
+
+		if err := tx.Create(role).Error; err != nil {
+			return err
+		}
+
+		for _, p := range resultado {
+			grant := &models.RolModuloPermiso{
+				IdRol:         role.ID,
+				IdModulo:      p.IdModulo,
+				IdPermisoTipo: p.IdPermisoTipo,
+				Efecto:        p.Efecto,
+				Alcance:       p.Alcance,
+			}
+			if err := tx.Create(grant).Error; err != nil {
+				return err
+			}
+		}
+
+		return checkRoleTreeSoD(tx, role.ID)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return role, nil
+}
+
+// validatePermisosPlantilla comprueba que los módulos y tipos de permiso
+// referenciados existan, que cada módulo ofrezca los permisos indicados y que
+// no haya entradas repetidas
+func validatePermisosPlantilla(db *gorm.DB, permisos []models.PlantillaRolPermiso) error {
+	vistos := make(map[[2]int]bool, len(permisos))
+	for _, p := range permisos {
+		objetivo := p.Objetivo()
+		if vistos[objetivo] {
+			return fmt.Errorf("la plantilla repite el permiso %d del módulo %d", objetivo[1], objetivo[0])
+		}
+		vistos[objetivo] = true
+
+		if p.IdModulo != nil {
+			var module models.Module
+			if err := db.Where("fecha_eliminacion IS NULL").First(&module, *p.IdModulo).Error; err != nil {
+				return fmt.Errorf("módulo %d no encontrado", *p.IdModulo)
+			}
+		}
+		if p.IdPermisoTipo != nil {
+			var permisoTipo models.PermisoTipo
+			if err := db.Where("fecha_eliminacion IS NULL").First(&permisoTipo, *p.IdPermisoTipo).Error; err != nil {
+				return fmt.Errorf("tipo de permiso %d no encontrado", *p.IdPermisoTipo)
+			}
+		}
+		if p.IdModulo != nil && p.IdPermisoTipo != nil {
+			if err := validatePermisosHabilitados(db, *p.IdModulo, []int{*p.IdPermisoTipo}); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,238 @@
+package repository
+
+import (
+	"auth-service/internal/models"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// errSimulacion revierte la transacción de una purga en modo simulación, de
+// modo que el reporte refleja exactamente lo que se habría eliminado
+var errSimulacion = errors.New("simulación de purga")
+
+// referenciasModulo enumera las columnas que referencian un módulo. Un módulo
+// solo se purga cuando ninguna fila de estas tablas lo referencia después de
+// purgar sus asignaciones y permisos eliminados.
+var referenciasModulo = []struct{ tabla, columna string }{
+	{"rol_modulo_permisos", "id_modulo"},
+	{"modulo_permisos", "id_modulo"},
+	{"recurso_permisos", "id_modulo"},
+	{"grupo_modulo_permisos", "id_modulo"},
+	{"solicitudes_elevacion", "id_modulo"},
+	{"aprobadores_elevacion", "id_modulo"},
+	{"delegacion_permisos", "id_modulo"},
+	{"plantilla_rol_permisos", "id_modulo"},
+	{"restricciones_sod", "id_modulo_a"},
+	{"restricciones_sod", "id_modulo_b"},
+}
+
+// tablasConLote enumera las tablas cuyas filas pueden pertenecer a un lote de
+// eliminación; un lote sin filas en ninguna de ellas ya no puede restaurarse
+var tablasConLote = []string{
+	"roles", "modulos", "rol_modulo_permisos", "modulo_permisos", "recurso_permisos",
+	"grupo_modulo_permisos", "delegacion_permisos", "plantilla_rol_permisos",
+}
+
+type PurgaRepository struct {
+	db *gorm.DB
+}
+
+func NewPurgaRepository(db *gorm.DB) *PurgaRepository {
+	return &PurgaRepository{db: db}
+}
+
+// WithContext devuelve una copia del repositorio cuyas consultas usan el
+// contexto indicado; con una organización en el contexto la purga se limita a
+// sus registros
+func (r *PurgaRepository) WithContext(ctx context.Context) *PurgaRepository {
+	return &PurgaRepository{db: r.db.WithContext(ctx)}
+}
+
+// Purge elimina definitivamente las asignaciones de rol, los permisos de
+// módulo y los módulos eliminados hace más de retencionDias días, en ese
+// orden y dentro de una transacción. Con dryRun la transacción se revierte y
+// el reporte indica lo que se habría eliminado. Los lotes de eliminación no
+// pertenecen a ninguna organización, así que solo los elimina la purga sin
+// organización (la programada o la de línea de comandos).
+func (r *PurgaRepository) Purge(retencionDias int, dryRun bool) (*models.PurgaReporte, error) {
+	if retencionDias < 1 {
+		return nil, fmt.Errorf("el periodo de retención debe ser de al menos un día")
+	}
+
+	now := time.Now()
+	reporte := &models.PurgaReporte{
+		DryRun:           dryRun,
+		RetencionDias:    retencionDias,
+		FechaCorte:       now.AddDate(0, 0, -retencionDias),
+		FechaEjecucion:   now,
+		Modulos:          []int{},
+		ModulosRetenidos: []int{},
+	}
+	organizacionID, limitada := OrganizacionDe(r.db.Statement.Context)
+	if limitada {
+		reporte.IdOrganizacion = &organizacionID
+	}
+
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := purgeGrants(tx, reporte); err != nil {
+			return err
+		}
+		if err := purgeModulePermissions(tx, reporte); err != nil {
+			return err
+		}
+		if err := purgeModules(tx, reporte); err != nil {
+			return err
+		}
+		if !limitada {
+			if err := purgeLotes(tx, reporte); err != nil {
+				return err
+			}
+		}
+		if dryRun {
+			return errSimulacion
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, errSimulacion) {
+		return nil, fmt.Errorf("error al purgar registros eliminados: %v", err)
+	}
+
+	return reporte, nil
+}
+
+// purgeGrants elimina las asignaciones de rol fuera de retención junto con
+// los valores de su alcance. Las del lote de un rol que aún puede restaurarse
+// se conservan para que la restauración recupere el rol con sus permisos.
+func purgeGrants(tx *gorm.DB, reporte *models.PurgaReporte) error {
+	// Lotes de roles que siguen eliminados en ese lote y pueden restaurarse
+	lotesRestaurables := tx.Table("lotes_eliminacion").
+		Joins("JOIN roles ON roles.id = lotes_eliminacion.id_entidad AND roles.id_lote_eliminacion = lotes_eliminacion.id").
+		Where("lotes_eliminacion.entidad = ?", models.EntidadRol).
+		Select("lotes_eliminacion.id")
+
+	if err := tx.Model(&models.RolModuloPermiso{}).
+		Where("fecha_eliminacion IS NOT NULL AND fecha_eliminacion < ?", reporte.FechaCorte).
+		Where("id_lote_eliminacion IN (?)", lotesRestaurables).
+		Count(&reporte.RolModuloPermisosRetenidos).Error; err != nil {
+		return err
+	}
+
+	var ids []int
+	if err := tx.Model(&models.RolModuloPermiso{}).
+		Where("fecha_eliminacion IS NOT NULL AND fecha_eliminacion < ?", reporte.FechaCorte).
+		Where("id_lote_eliminacion IS NULL OR id_lote_eliminacion NOT IN (?)", lotesRestaurables).
+		Pluck("id", &ids).Error; err != nil {
+		return err
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+
+	result := tx.Where("id_rol_modulo_permiso IN ?", ids).Delete(&models.RolModuloPermisoAlcance{})
+	if result.Error != nil {
+		return result.Error
+	}
+	reporte.ValoresAlcance = result.RowsAffected
+
+	result = tx.Where("id IN ?", ids).Delete(&models.RolModuloPermiso{})
+	if result.Error != nil {
+		return result.Error
+	}
+	reporte.RolModuloPermisos = result.RowsAffected
+	return nil
+}
+
+// purgeModulePermissions elimina los permisos de módulo fuera de retención
+func purgeModulePermissions(tx *gorm.DB, reporte *models.PurgaReporte) error {
+	result := tx.Where("fecha_eliminacion IS NOT NULL AND fecha_eliminacion < ?", reporte.FechaCorte).
+		Delete(&models.ModuloPermiso{})
+	if result.Error != nil {
+		return result.Error
+	}
+	reporte.ModuloPermisos = result.RowsAffected
+	return nil
+}
+
+// purgeModules elimina los módulos fuera de retención que ya no están
+// referenciados. Un módulo con submódulos solo se purga si también se purgan
+// todos ellos; los demás se informan como retenidos.
+func purgeModules(tx *gorm.DB, reporte *models.PurgaReporte) error {
+	var candidatos []int
+	if err := tx.Model(&models.Module{}).
+		Where("fecha_eliminacion IS NOT NULL AND fecha_eliminacion < ?", reporte.FechaCorte).
+		Order("id").
+		Pluck("id", &candidatos).Error; err != nil {
+		return err
+	}
+	if len(candidatos) == 0 {
+		return nil
+	}
+
+	retenidos := make(map[int]bool)
+	for _, ref := range referenciasModulo {
+		var referenciados []int
+		if err := tx.Table(ref.tabla).
+			Where(ref.columna+" IN ?", candidatos).
+			Distinct().
+			Pluck(ref.columna, &referenciados).Error; err != nil {
+			return err
+		}
+		for _, id := range referenciados {
+			retenidos[id] = true
+		}
+	}
+
+	// Un submódulo que se conserva retiene a su padre, y así hacia arriba
+	var hijos []models.Module
+	if err := tx.Select("id, id_modulo_padre").
+		Where("id_modulo_padre IN ?", candidatos).
+		Find(&hijos).Error; err != nil {
+		return err
+	}
+	purgable := make(map[int]bool, len(candidatos))
+	for _, id := range candidatos {
+		purgable[id] = !retenidos[id]
+	}
+	for cambio := true; cambio; {
+		cambio = false
+		for _, hijo := range hijos {
+			padre := *hijo.IdModuloPadre
+			if purgable[padre] && !purgable[hijo.ID] {
+				purgable[padre] = false
+				cambio = true
+			}
+		}
+	}
+
+	for _, id := range candidatos {
+		if purgable[id] {
+			reporte.Modulos = append(reporte.Modulos, id)
+		} else {
+			reporte.ModulosRetenidos = append(reporte.ModulosRetenidos, id)
+		}
+	}
+	if len(reporte.Modulos) == 0 {
+		return nil
+	}
+
+	return tx.Where("id IN ?", reporte.Modulos).Delete(&models.Module{}).Error
+}
+
+// purgeLotes elimina los lotes de eliminación fuera de retención que ya no
+// tienen filas
+func purgeLotes(tx *gorm.DB, reporte *models.PurgaReporte) error {
+	query := tx.Where("fecha_eliminacion < ?", reporte.FechaCorte)
+	for _, tabla := range tablasConLote {
+		query = query.Where("NOT EXISTS (SELECT 1 FROM " + tabla + " WHERE " + tabla + ".id_lote_eliminacion = lotes_eliminacion.id)")
+	}
+	result := query.Delete(&models.LoteEliminacion{})
+	if result.Error != nil {
+		return result.Error
+	}
+	reporte.LotesEliminacion = result.RowsAffected
+	return nil
+}
@@ -0,0 +1,151 @@
+package repository
+
+import (
+	"auth-service/internal/models"
+	"context"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type RecursoPermisoRepository struct {
+	db *gorm.DB
+}
+
+func NewRecursoPermisoRepository(db *gorm.DB) *RecursoPermisoRepository {
+	return &RecursoPermisoRepository{db: db}
+}
+
+// WithContext devuelve una copia del repositorio cuyas consultas usan el
+// contexto indicado, incluida la organización de la petición
+func (r *RecursoPermisoRepository) WithContext(ctx context.Context) *RecursoPermisoRepository {
+	return &RecursoPermisoRepository{db: r.db.WithContext(ctx)}
+}
+
+func (r *RecursoPermisoRepository) Create(grant *models.RecursoPermiso) error {
+	if (grant.IdUsuario == nil) == (grant.IdRol == nil) {
+		return fmt.Errorf("el permiso debe concederse a un usuario o a un rol")
+	}
+
+	var module models.Module
+	if err := r.db.Where("fecha_eliminacion IS NULL").First(&module, grant.IdModulo).Error; err != nil {
+		return fmt.Errorf("módulo no encontrado: %v", err)
+	}
+
+	var permisoTipo models.PermisoTipo
+	if err := r.db.Where("fecha_eliminacion IS NULL").First(&permisoTipo, grant.IdPermisoTipo).Error; err != nil {
+		return fmt.Errorf("tipo de permiso no encontrado: %v", err)
+	}
+
+	if grant.IdUsuario != nil {
+		var user models.User
+		if err := r.db.Where("fecha_eliminacion IS NULL").First(&user, *grant.IdUsuario).Error; err != nil {
+			return fmt.Errorf("usuario no encontrado: %v", err)
+		}
+	}
+	if grant.IdRol != nil {
+		var role models.Role
+		if err := r.db.Where("fecha_eliminacion IS NULL").First(&role, *grant.IdRol).Error; err != nil {
+			return fmt.Errorf("rol no encontrado: %v", err)
+		}
+	}
+
+	var exists bool
+	query := r.db.Model(&models.RecursoPermiso{}).
+		Where("id_modulo = ? AND tipo_recurso = ? AND id_recurso = ? AND id_permiso_tipo = ? AND fecha_eliminacion IS NULL",
+			grant.IdModulo, grant.TipoRecurso, grant.IdRecurso, grant.IdPermisoTipo)
+	if grant.IdUsuario != nil {
+		query = query.Where("id_usuario = ?", *grant.IdUsuario)
+	} else {
+		query = query.Where("id_rol = ?", *grant.IdRol)
+	}
+	if err := query.Select("count(*) > 0").Scan(&exists).Error; err != nil {
+		return err
+	}
+	if exists {
+		return fmt.Errorf("el permiso sobre este recurso ya fue concedido")
+	}
+
+	return r.db.Create(grant).Error
+}
+
+func (r *RecursoPermisoRepository) GetAll(filter models.RecursoPermisoFilter) ([]models.RecursoPermiso, error) {
+	query := r.db.Where("fecha_eliminacion IS NULL").Preload("PermisoTipo")
+	if filter.IdModulo != 0 {
+		query = query.Where("id_modulo = ?", filter.IdModulo)
+	}
+	if filter.TipoRecurso != "" {
+		query = query.Where("tipo_recurso = ?", filter.TipoRecurso)
+	}
+	if filter.IdRecurso != "" {
+		query = query.Where("id_recurso = ?", filter.IdRecurso)
+	}
+	if filter.IdUsuario != 0 {
+		query = query.Where("id_usuario = ?", filter.IdUsuario)
+	}
+	if filter.IdRol != 0 {
+		query = query.Where("id_rol = ?", filter.IdRol)
+	}
+
+	var grants []models.RecursoPermiso
+	err := query.Find(&grants).Error
+	return grants, err
+}
+
+func (r *RecursoPermisoRepository) GetByID(id int) (*models.RecursoPermiso, error) {
+	var grant models.RecursoPermiso
+	err := r.db.Where("fecha_eliminacion IS NULL").Preload("PermisoTipo").First(&grant, id).Error
+	if err != nil {
+		return nil, fmt.Errorf("permiso de recurso no encontrado: %v", err)
+	}
+	return &grant, nil
+}
+
+func (r *RecursoPermisoRepository) UpdatePermisoTipo(id, permisoTipoID int) error {
+	var permisoTipo models.PermisoTipo
+	if err := r.db.Where("fecha_eliminacion IS NULL").First(&permisoTipo, permisoTipoID).Error; err != nil {
+		return fmt.Errorf("tipo de permiso no encontrado: %v", err)
+	}
+
+	result := r.db.Model(&models.RecursoPermiso{}).
+		Where("id = ? AND fecha_eliminacion IS NULL", id).
+		Update("id_permiso_tipo", permisoTipoID)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("permiso de recurso no encontrado")
+	}
+	return nil
+}
+
+func (r *RecursoPermisoRepository) Delete(id int) error {
+	result := r.db.Model(&models.RecursoPermiso{}).
+		Where("id = ? AND fecha_eliminacion IS NULL", id).
+		Update("fecha_eliminacion", time.Now())
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("permiso de recurso no encontrado")
+	}
+	return nil
+}
+
+// hasResourceGrant indica si el usuario, directamente o por alguno de sus
+// roles, tiene el permiso sobre el recurso concreto
+func hasResourceGrant(db *gorm.DB, userID int, roleIDs []int, moduleID int, tipoRecurso, idRecurso, codigo string) (bool, error) {
+	var exists bool
+	err := db.Model(&models.RecursoPermiso{}).
+		Joins("JOIN permiso_tipos ON permiso_tipos.id = recurso_permisos.id_permiso_tipo").
+		Where("recurso_permisos.fecha_eliminacion IS NULL").
+		Where("recurso_permisos.id_modulo = ? AND recurso_permisos.tipo_recurso = ? AND recurso_permisos.id_recurso = ?",
+			moduleID, tipoRecurso, idRecurso).
+		Where("permiso_tipos.codigo = ?", codigo).
+		Where("recurso_permisos.id_usuario = ? OR recurso_permisos.id_rol IN ?", userID, roleIDs).
+		Scopes(asignacionActiva("recurso_permisos")).
+		Select("count(*) > 0").
+		Scan(&exists).Error
+	return exists, err
+}
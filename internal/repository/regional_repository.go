@@ -0,0 +1,146 @@
+package repository
+
+import (
+	"auth-service/internal/models"
+	"context"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// CatalogoEnUsoError indica que una sede o regional no se puede eliminar
+// porque aún la referencian usuarios, sedes o asignaciones con alcance
+type CatalogoEnUsoError struct {
+	Entidad      string
+	Usuarios     int64
+	Sedes        int64
+	Asignaciones int64
+}
+
+func (e *CatalogoEnUsoError) Error() string {
+	return fmt.Sprintf("la %s está en uso por %d usuarios, %d sedes y %d asignaciones de permisos",
+		e.Entidad, e.Usuarios, e.Sedes, e.Asignaciones)
+}
+
+type RegionalRepository struct {
+	db *gorm.DB
+}
+
+func NewRegionalRepository(db *gorm.DB) *RegionalRepository {
+	return &RegionalRepository{db: db}
+}
+
+// WithContext devuelve una copia del repositorio cuyas consultas usan el
+// contexto indicado, incluida la organización de la petición
+func (r *RegionalRepository) WithContext(ctx context.Context) *RegionalRepository {
+	return &RegionalRepository{db: r.db.WithContext(ctx)}
+}
+
+func (r *RegionalRepository) Create(regional *models.Regional) error {
+	regional.Nombre = models.NombreCatalogo(regional.Nombre)
+	regional.Clave = models.ClaveCatalogo(regional.Nombre)
+	if regional.Clave == "" {
+		return fmt.Errorf("el nombre de la regional es obligatorio")
+	}
+	if err := regionalDisponible(r.db, regional.Clave, 0); err != nil {
+		return err
+	}
+
+	regional.FechaCreacion = time.Now()
+	regional.FechaActualizacion = time.Now()
+	return r.db.Create(regional).Error
+}
+
+func (r *RegionalRepository) GetAll() ([]models.Regional, error) {
+	var regionales []models.Regional
+	err := r.db.Order("nombre").Find(&regionales).Error
+	return regionales, err
+}
+
+func (r *RegionalRepository) GetByID(id int) (*models.Regional, error) {
+	var regional models.Regional
+	if err := r.db.First(&regional, id).Error; err != nil {
+		return nil, fmt.Errorf("regional no encontrada: %v", err)
+	}
+	return &regional, nil
+}
+
+// Update renombra la regional y actualiza el nombre guardado en sus usuarios
+// y en los alcances de las asignaciones
+func (r *RegionalRepository) Update(id int, nombre string) (*models.Regional, error) {
+	regional, err := r.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	nombre = models.NombreCatalogo(nombre)
+	clave := models.ClaveCatalogo(nombre)
+	if clave == "" {
+		return nil, fmt.Errorf("el nombre de la regional es obligatorio")
+	}
+
+	err = r.db.Transaction(func(tx *gorm.DB) error {
+		if err := regionalDisponible(tx, clave, id); err != nil {
+			return err
+		}
+		if err := tx.Model(regional).Updates(map[string]interface{}{
+			"nombre":              nombre,
+			"clave":               clave,
+			"fecha_actualizacion": time.Now(),
+		}).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&models.User{}).Where("id_regional = ?", id).Update("regional", nombre).Error; err != nil {
+			return err
+		}
+		return renombrarValoresAlcance(tx, models.AlcanceRegional, regional.Nombre, nombre, false)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return r.GetByID(id)
+}
+
+// Delete elimina la regional si ninguna sede, usuario ni asignación activa la referencia
+func (r *RegionalRepository) Delete(id int) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var regional models.Regional
+		if err := tx.First(&regional, id).Error; err != nil {
+			return fmt.Errorf("regional no encontrada: %v", err)
+		}
+
+		enUso := &CatalogoEnUsoError{Entidad: "regional"}
+		if err := tx.Model(&models.Sede{}).Where("id_regional = ?", id).Count(&enUso.Sedes).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&models.User{}).Where("id_regional = ?", id).Count(&enUso.Usuarios).Error; err != nil {
+			return err
+		}
+		valores, err := valoresAlcanceDe(tx, models.AlcanceRegional, models.ClaveCatalogo(regional.Nombre), true)
+		if err != nil {
+			return err
+		}
+		enUso.Asignaciones = asignacionesDe(valores)
+		if enUso.Sedes > 0 || enUso.Usuarios > 0 || enUso.Asignaciones > 0 {
+			return enUso
+		}
+
+		return tx.Delete(&models.Regional{}, id).Error
+	})
+}
+
+// regionalDisponible comprueba que ninguna otra regional tenga el mismo nombre
+func regionalDisponible(db *gorm.DB, clave string, id int) error {
+	var exists bool
+	if err := db.Model(&models.Regional{}).
+		Where("clave = ? AND id != ?", clave, id).
+		Select("count(*) > 0").
+		Scan(&exists).Error; err != nil {
+		return err
+	}
+	if exists {
+		return fmt.Errorf("ya existe una regional con este nombre")
+	}
+	return nil
+}
@@ -0,0 +1,343 @@
+package repository
+
+import (
+	"auth-service/internal/models"
+	"slices"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Este archivo reúne la resolución de permisos efectivos. Todas las lecturas
+// de asignaciones para resolver permisos (listados de usuario y de rol,
+// autorización, delegaciones y separación de funciones) pasan por
+// asignacionActiva, activeGrants y activeGroupGrants, de modo que las filas
+// eliminadas y las de roles, módulos o tipos de permiso eliminados nunca
+// forman parte de los permisos efectivos.
+
+// asignacionActiva limita la consulta a las filas de la tabla indicada cuyo
+// módulo y tipo de permiso siguen activos; un valor nulo es un comodín
+func asignacionActiva(tabla string) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("(" + tabla + ".id_modulo IS NULL OR " + tabla + ".id_modulo IN (SELECT id FROM modulos WHERE fecha_eliminacion IS NULL))").
+			Where("(" + tabla + ".id_permiso_tipo IS NULL OR " + tabla + ".id_permiso_tipo IN (SELECT id FROM permiso_tipos WHERE fecha_eliminacion IS NULL))")
+	}
+}
+
+// grantActivo limita la consulta a las asignaciones de rol_modulo_permisos no
+// eliminadas, de roles no eliminados y sobre módulos y tipos de permiso activos
+func grantActivo(db *gorm.DB) *gorm.DB {
+	db = db.Where("rol_modulo_permisos.fecha_eliminacion IS NULL").
+		Where("rol_modulo_permisos.id_rol IN (SELECT id FROM roles WHERE fecha_eliminacion IS NULL)")
+	return asignacionActiva("rol_modulo_permisos")(db)
+}
+
+// activeGrants devuelve las asignaciones efectivas de los roles indicados, con
+// los comodines y submódulos expandidos y Modulo, PermisoTipo y Valores
+// cargados. Con now solo se incluyen las vigentes en ese instante; sin él se
+// incluyen también las de validez futura o vencida.
+func activeGrants(db *gorm.DB, roleIDs []int, now *time.Time, preloads ...string) ([]models.RolModuloPermiso, error) {
+	if len(roleIDs) == 0 {
+		return nil, nil
+	}
+
+	query := db.Where("rol_modulo_permisos.id_rol IN ?", roleIDs).
+		Scopes(grantActivo).
+		Preload("Modulo").
+		Preload("PermisoTipo").
+		Preload("Valores")
+	if now != nil {
+		query = query.Scopes(grantVigente(*now))
+	}
+	for _, preload := range preloads {
+		query = query.Preload(preload)
+	}
+
+	var grants []models.RolModuloPermiso
+	if err := query.Find(&grants).Error; err != nil {
+		return nil, err
+	}
+
+	grants, err := expandGrants(db, grants)
+	if err != nil {
+		return nil, err
+	}
+	return grantsEfectivos(grants, now), nil
+}
+
+// grantsEfectivos descarta las asignaciones eliminadas, las que no tienen
+// módulo o tipo de permiso concretos, las de módulos o tipos de permiso
+// eliminados y, con now, las que no están vigentes
+func grantsEfectivos(grants []models.RolModuloPermiso, now *time.Time) []models.RolModuloPermiso {
+	efectivos := make([]models.RolModuloPermiso, 0, len(grants))
+	for _, grant := range grants {
+		if grant.FechaEliminacion != nil || grant.Modulo == nil || grant.PermisoTipo == nil {
+			continue
+		}
+		if grant.Modulo.FechaEliminacion != nil || grant.PermisoTipo.FechaEliminacion != nil {
+			continue
+		}
+		if now != nil && !grant.Vigente(*now) {
+			continue
+		}
+		efectivos = append(efectivos, grant)
+	}
+	return efectivos
+}
+
+// activeGroupGrants devuelve los permisos directos de los grupos indicados
+// sobre módulos y tipos de permiso activos, con Modulo y PermisoTipo cargados
+func activeGroupGrants(db *gorm.DB, grupoIDs []int) ([]models.GrupoModuloPermiso, error) {
+	if len(grupoIDs) == 0 {
+		return nil, nil
+	}
+
+	var grupoPermisos []models.GrupoModuloPermiso
+	if err := db.Where("grupo_modulo_permisos.id_grupo IN ? AND grupo_modulo_permisos.fecha_eliminacion IS NULL", grupoIDs).
+		Scopes(asignacionActiva("grupo_modulo_permisos")).
+		Preload("Modulo").
+		Preload("PermisoTipo").
+		Find(&grupoPermisos).Error; err != nil {
+		return nil, err
+	}
+	return grupoPermisosEfectivos(grupoPermisos), nil
+}
+
+// grupoPermisosEfectivos descarta los permisos de grupo eliminados y los que
+// son sobre módulos o tipos de permiso eliminados
+func grupoPermisosEfectivos(grupoPermisos []models.GrupoModuloPermiso) []models.GrupoModuloPermiso {
+	efectivos := make([]models.GrupoModuloPermiso, 0, len(grupoPermisos))
+	for _, gp := range grupoPermisos {
+		if gp.FechaEliminacion != nil || gp.Modulo.FechaEliminacion != nil || gp.PermisoTipo.FechaEliminacion != nil {
+			continue
+		}
+		efectivos = append(efectivos, gp)
+	}
+	return efectivos
+}
+
+// permisosPorModulo acumula los permisos efectivos de un usuario por módulo
+type permisosPorModulo map[int]*models.ModuloPermissions
+
+// agregar registra un permiso en el módulo; los módulos eliminados se ignoran
+func (p permisosPorModulo) agregar(modulo models.Module, origen models.PermisoOrigen) {
+	if modulo.FechaEliminacion != nil {
+		return
+	}
+	mp, exists := p[modulo.ID]
+	if !exists {
+		mp = &models.ModuloPermissions{
+			ID:        modulo.ID,
+			Codigo:    modulo.Codigo,
+			Nombre:    modulo.Nombre,
+			Permisos:  make([]string, 0),
+			Denegados: make([]string, 0),
+			Detalle:   make([]models.PermisoOrigen, 0),
+		}
+		p[modulo.ID] = mp
+	}
+	mp.Detalle = append(mp.Detalle, origen)
+
+	// Solo una denegación global bloquea el permiso en el listado; las
+	// denegaciones con alcance se evalúan por recurso al autorizar
+	codigos := &mp.Permisos
+	if origen.Efecto == models.EfectoDenegar {
+		if origen.Alcance != models.AlcanceGlobal {
+			return
+		}
+		codigos = &mp.Denegados
+	}
+	if !slices.Contains(*codigos, origen.Codigo) {
+		*codigos = append(*codigos, origen.Codigo)
+	}
+}
+
+// permitido indica si el código está concedido en el módulo y no está denegado
+func (p permisosPorModulo) permitido(moduloID int, codigo string) bool {
+	mp, exists := p[moduloID]
+	if !exists {
+		return false
+	}
+	return slices.Contains(mp.Permisos, codigo) && !slices.Contains(mp.Denegados, codigo)
+}
+
+// lista aplica las denegaciones, que prevalecen sobre cualquier permiso
+// concedido, y devuelve los módulos como slice
+func (p permisosPorModulo) lista() []models.ModuloPermissions {
+	modulePermsList := make([]models.ModuloPermissions, 0, len(p))
+	for _, mp := range p {
+		mp.Permisos = slices.DeleteFunc(mp.Permisos, func(codigo string) bool {
+			return slices.Contains(mp.Denegados, codigo)
+		})
+		modulePermsList = append(modulePermsList, *mp)
+	}
+	return modulePermsList
+}
+
+// fuenteRol indica por qué un rol forma parte de los roles efectivos de un usuario
+type fuenteRol struct {
+	origen  string
+	idGrupo int
+}
+
+// userRoleSources devuelve los roles efectivos del usuario: su rol (si la
+// asignación está vigente), los roles de sus grupos y los ancestros de todos
+// ellos, omitiendo los roles eliminados; también devuelve los grupos del usuario
+func userRoleSources(db *gorm.DB, user *models.User, now time.Time) (map[int]fuenteRol, []int, error) {
+	fuentes := make(map[int]fuenteRol)
+	agregarConAncestros := func(roleID int, directa, heredada fuenteRol) error {
+		var activo bool
+		if err := db.Model(&models.Role{}).
+			Where("id = ? AND fecha_eliminacion IS NULL", roleID).
+			Select("count(*) > 0").
+			Scan(&activo).Error; err != nil {
+			return err
+		}
+		if !activo {
+			return nil
+		}
+		if _, exists := fuentes[roleID]; !exists {
+			fuentes[roleID] = directa
+		}
+		ancestorIDs, err := roleAncestorIDs(db, roleID)
+		if err != nil {
+			return err
+		}
+		for _, id := range ancestorIDs {
+			if _, exists := fuentes[id]; !exists {
+				fuentes[id] = heredada
+			}
+		}
+		return nil
+	}
+
+	if user.RolVigente(now) {
+		if err := agregarConAncestros(user.IdRol,
+			fuenteRol{origen: models.OrigenRol},
+			fuenteRol{origen: models.OrigenHeredado}); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	grupoIDs, err := userGroupIDs(db, user.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(grupoIDs) > 0 {
+		var grupoRoles []models.GrupoRol
+		if err := db.Where("id_grupo IN ?", grupoIDs).Order("id").Find(&grupoRoles).Error; err != nil {
+			return nil, nil, err
+		}
+		for _, gr := range grupoRoles {
+			fuente := fuenteRol{origen: models.OrigenGrupo, idGrupo: gr.IdGrupo}
+			if err := agregarConAncestros(gr.IdRol, fuente, fuente); err != nil {
+				return nil, nil, err
+			}
+		}
+	}
+
+	return fuentes, grupoIDs, nil
+}
+
+// addRolePermissions agrega los permisos vigentes de los roles efectivos del
+// usuario y los permisos directos de sus grupos
+func addRolePermissions(db *gorm.DB, permisos permisosPorModulo, user *models.User, now time.Time) error {
+	fuentes, grupoIDs, err := userRoleSources(db, user, now)
+	if err != nil {
+		return err
+	}
+
+	roleIDs := make([]int, 0, len(fuentes))
+	for id := range fuentes {
+		roleIDs = append(roleIDs, id)
+	}
+
+	rolModuloPermisos, err := activeGrants(db, roleIDs, &now)
+	if err != nil {
+		return err
+	}
+
+	for _, rmp := range rolModuloPermisos {
+		fuente := fuentes[rmp.IdRol]
+		origen := models.PermisoOrigen{
+			Codigo:      rmp.PermisoTipo.Codigo,
+			Efecto:      rmp.Efecto,
+			Alcance:     rmp.Alcance,
+			Valores:     scopeValuesForUser(&rmp, user),
+			Origen:      fuente.origen,
+			IdGrupo:     fuente.idGrupo,
+			ValidoHasta: rmp.ValidoHasta,
+		}
+		if fuente.origen != models.OrigenRol {
+			origen.IdRolOrigen = rmp.IdRol
+		}
+		permisos.agregar(*rmp.Modulo, origen)
+	}
+
+	// Permisos de módulo asignados directamente a los grupos del usuario
+	grupoPermisos, err := activeGroupGrants(db, grupoIDs)
+	if err != nil {
+		return err
+	}
+	for _, gp := range grupoPermisos {
+		permisos.agregar(gp.Modulo, models.PermisoOrigen{
+			Codigo:  gp.PermisoTipo.Codigo,
+			Efecto:  gp.Efecto,
+			Alcance: models.AlcanceGlobal,
+			Origen:  models.OrigenGrupo,
+			IdGrupo: gp.IdGrupo,
+		})
+	}
+
+	return nil
+}
+
+// resolveUserPermissions devuelve los permisos efectivos del usuario: los de
+// sus roles y grupos, sus elevaciones vigentes y los permisos que le delegaron.
+// Una cuenta que no está activa no tiene permisos efectivos.
+func resolveUserPermissions(db *gorm.DB, user *models.User, now time.Time) (permisosPorModulo, error) {
+	permisos := make(permisosPorModulo)
+	if !user.Activo() {
+		return permisos, nil
+	}
+
+	// Permisos del rol del usuario, de sus grupos y de los roles de los que heredan
+	if err := addRolePermissions(db, permisos, user, now); err != nil {
+		return nil, err
+	}
+
+	// Elevaciones temporales aprobadas y aún vigentes
+	elevaciones, err := activeElevations(db, user.ID, now)
+	if err != nil {
+		return nil, err
+	}
+	for _, elevacion := range elevaciones {
+		permisos.agregar(elevacion.Modulo, models.PermisoOrigen{
+			Codigo:      elevacion.PermisoTipo.Codigo,
+			Efecto:      models.EfectoPermitir,
+			Alcance:     models.AlcanceGlobal,
+			Origen:      models.OrigenElevacion,
+			IdElevacion: elevacion.ID,
+			ValidoHasta: elevacion.FechaExpiracion,
+		})
+	}
+
+	// Permisos delegados por otros usuarios
+	if err := addDelegatedPermissions(db, permisos, user, now); err != nil {
+		return nil, err
+	}
+	return permisos, nil
+}
+
+// scopeValuesForUser devuelve los valores del alcance del permiso, resolviendo
+// los alcances relativos a la sede o regional del propio usuario
+func scopeValuesForUser(rmp *models.RolModuloPermiso, user *models.User) []string {
+	switch rmp.Alcance {
+	case models.AlcanceMismaSede:
+		return []string{user.Sede}
+	case models.AlcanceMismaRegional:
+		return []string{user.Regional}
+	default:
+		return rmp.ValoresAlcance()
+	}
+}
@@ -0,0 +1,337 @@
+package repository
+
+import (
+	"auth-service/internal/models"
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+// baseFalsa es una base de datos en memoria para probar los repositorios sin
+// PostgreSQL. Responde cada consulta con las filas de la tabla de su cláusula
+// FROM sin evaluar las condiciones, así que cada prueba carga solo las filas
+// que la consulta real devolvería.
+type baseFalsa struct {
+	tablas map[string][]map[string]driver.Value
+}
+
+func (b *baseFalsa) Connect(context.Context) (driver.Conn, error) { return conexionFalsa{b}, nil }
+func (b *baseFalsa) Driver() driver.Driver                        { return nil }
+
+type conexionFalsa struct{ base *baseFalsa }
+
+func (c conexionFalsa) Prepare(string) (driver.Stmt, error) { return nil, driver.ErrSkip }
+func (c conexionFalsa) Close() error                        { return nil }
+func (c conexionFalsa) Begin() (driver.Tx, error)           { return c, nil }
+func (c conexionFalsa) Commit() error                       { return nil }
+func (c conexionFalsa) Rollback() error                     { return nil }
+
+func (c conexionFalsa) ExecContext(context.Context, string, []driver.NamedValue) (driver.Result, error) {
+	return driver.RowsAffected(0), nil
+}
+
+func (c conexionFalsa) QueryContext(_ context.Context, query string, _ []driver.NamedValue) (driver.Rows, error) {
+	seleccion, resto, found := strings.Cut(strings.TrimPrefix(query, "SELECT "), " FROM ")
+	if !found {
+		return nil, fmt.Errorf("consulta no soportada: %s", query)
+	}
+	filas := c.base.tablas[strings.Trim(strings.Fields(resto)[0], `"`)]
+
+	switch seleccion {
+	case "count(*)":
+		return &filasFalsas{columnas: []string{"count"}, valores: [][]driver.Value{{int64(len(filas))}}}, nil
+	case "count(*) > 0":
+		return &filasFalsas{columnas: []string{"existe"}, valores: [][]driver.Value{{len(filas) > 0}}}, nil
+	}
+
+	var columnas []string
+	if seleccion == "*" {
+		for _, fila := range filas {
+			for columna := range fila {
+				if !slices.Contains(columnas, columna) {
+					columnas = append(columnas, columna)
+				}
+			}
+		}
+	} else {
+		for _, columna := range strings.Split(seleccion, ",") {
+			partes := strings.Split(columna, ".")
+			columnas = append(columnas, strings.Trim(partes[len(partes)-1], `"`))
+		}
+	}
+
+	resultado := &filasFalsas{columnas: columnas}
+	for _, fila := range filas {
+		valores := make([]driver.Value, len(columnas))
+		for i, columna := range columnas {
+			valores[i] = fila[columna]
+		}
+		resultado.valores = append(resultado.valores, valores)
+	}
+	return resultado, nil
+}
+
+type filasFalsas struct {
+	columnas []string
+	valores  [][]driver.Value
+}
+
+func (f *filasFalsas) Columns() []string { return f.columnas }
+func (f *filasFalsas) Close() error      { return nil }
+
+func (f *filasFalsas) Next(dest []driver.Value) error {
+	if len(f.valores) == 0 {
+		return io.EOF
+	}
+	copy(dest, f.valores[0])
+	f.valores = f.valores[1:]
+	return nil
+}
+
+// abrirBaseFalsa devuelve una conexión de gorm sobre la base en memoria
+func abrirBaseFalsa(t *testing.T, tablas map[string][]map[string]driver.Value) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(postgres.New(postgres.Config{Conn: sql.OpenDB(&baseFalsa{tablas: tablas})}), &gorm.Config{
+		DisableAutomaticPing: true,
+		NamingStrategy:       schema.NamingStrategy{SingularTable: true},
+	})
+	if err != nil {
+		t.Fatalf("no se pudo preparar la conexión: %v", err)
+	}
+	return db
+}
+
+func TestRoleDecision(t *testing.T) {
+	now := time.Now()
+	antes := now.Add(-time.Hour)
+	despues := now.Add(time.Hour)
+	user := &models.User{ID: 1, Sede: "Norte", Regional: "Andina"}
+
+	permitir := models.RolModuloPermiso{ID: 1, Efecto: models.EfectoPermitir, Alcance: models.AlcanceGlobal}
+	denegar := models.RolModuloPermiso{ID: 2, Efecto: models.EfectoDenegar, Alcance: models.AlcanceGlobal}
+	conAlcance := func(grant models.RolModuloPermiso, alcance string, valores ...string) models.RolModuloPermiso {
+		grant.Alcance = alcance
+		for _, valor := range valores {
+			grant.Valores = append(grant.Valores, models.RolModuloPermisoAlcance{Valor: valor})
+		}
+		return grant
+	}
+	conVentana := func(grant models.RolModuloPermiso, desde, hasta *time.Time) models.RolModuloPermiso {
+		grant.ValidoDesde, grant.ValidoHasta = desde, hasta
+		return grant
+	}
+	eliminada := permitir
+	eliminada.FechaEliminacion = &antes
+
+	casos := []struct {
+		nombre         string
+		grupoPermisos  []models.GrupoModuloPermiso
+		grants         []models.RolModuloPermiso
+		sede, regional string
+		esperado       int
+	}{
+		{nombre: "sin asignaciones", esperado: sinDecision},
+		{nombre: "permiso global", grants: []models.RolModuloPermiso{permitir}, esperado: decisionPermitida},
+		{nombre: "la denegación prevalece", grants: []models.RolModuloPermiso{permitir, denegar}, esperado: decisionDenegada},
+		{nombre: "denegación de un grupo", grupoPermisos: []models.GrupoModuloPermiso{{Efecto: models.EfectoDenegar}},
+			grants: []models.RolModuloPermiso{permitir}, esperado: decisionDenegada},
+		{nombre: "permiso de un grupo", grupoPermisos: []models.GrupoModuloPermiso{{Efecto: models.EfectoPermitir}},
+			esperado: decisionPermitida},
+		{nombre: "denegación de grupo eliminada", grupoPermisos: []models.GrupoModuloPermiso{{Efecto: models.EfectoDenegar, FechaEliminacion: &antes}},
+			grants: []models.RolModuloPermiso{permitir}, esperado: decisionPermitida},
+		{nombre: "sede dentro del alcance", grants: []models.RolModuloPermiso{conAlcance(permitir, models.AlcanceSede, "Norte", "Centro")},
+			sede: "Norte", esperado: decisionPermitida},
+		{nombre: "sede fuera del alcance", grants: []models.RolModuloPermiso{conAlcance(permitir, models.AlcanceSede, "Norte")},
+			sede: "Sur", esperado: sinDecision},
+		{nombre: "recurso sin sede no se concede", grants: []models.RolModuloPermiso{conAlcance(permitir, models.AlcanceSede, "Norte")},
+			esperado: sinDecision},
+		{nombre: "recurso sin sede sí se deniega", grants: []models.RolModuloPermiso{permitir, conAlcance(denegar, models.AlcanceSede, "Norte")},
+			esperado: decisionDenegada},
+		{nombre: "misma regional del usuario", grants: []models.RolModuloPermiso{conAlcance(permitir, models.AlcanceMismaRegional)},
+			regional: "Andina", esperado: decisionPermitida},
+		{nombre: "otra regional que la del usuario", grants: []models.RolModuloPermiso{conAlcance(permitir, models.AlcanceMismaRegional)},
+			regional: "Caribe", esperado: sinDecision},
+		{nombre: "denegación de otra sede no aplica", grants: []models.RolModuloPermiso{permitir, conAlcance(denegar, models.AlcanceSede, "Sur")},
+			sede: "Norte", esperado: decisionPermitida},
+		{nombre: "asignación vencida", grants: []models.RolModuloPermiso{conVentana(permitir, nil, &antes)}, esperado: sinDecision},
+		{nombre: "asignación futura", grants: []models.RolModuloPermiso{conVentana(permitir, &despues, nil)}, esperado: sinDecision},
+		{nombre: "asignación vigente", grants: []models.RolModuloPermiso{conVentana(permitir, &antes, &despues)}, esperado: decisionPermitida},
+		{nombre: "denegación vencida no aplica", grants: []models.RolModuloPermiso{permitir, conVentana(denegar, nil, &antes)},
+			esperado: decisionPermitida},
+		{nombre: "asignación eliminada", grants: []models.RolModuloPermiso{eliminada}, esperado: sinDecision},
+	}
+
+	for _, c := range casos {
+		if got := roleDecision(c.grupoPermisos, c.grants, user, c.sede, c.regional, now); got != c.esperado {
+			t.Errorf("%s: se obtuvo la decisión %d, se esperaba %d", c.nombre, got, c.esperado)
+		}
+	}
+}
+
+func TestCheck(t *testing.T) {
+	now := time.Now()
+	usuario := func(estado string) map[string]driver.Value {
+		return map[string]driver.Value{"id": int64(1), "id_rol": int64(10), "estado": estado, "sede": "Norte"}
+	}
+	grant := func(id int64, efecto, alcance string) map[string]driver.Value {
+		return map[string]driver.Value{"id": id, "id_rol": int64(10), "id_modulo": int64(5), "id_permiso_tipo": int64(2),
+			"efecto": efecto, "alcance": alcance}
+	}
+	base := func(estado string, grants ...map[string]driver.Value) map[string][]map[string]driver.Value {
+		return map[string][]map[string]driver.Value{
+			"usuarios":            {usuario(estado)},
+			"roles":               {{"id": int64(10)}},
+			"modulos":             {{"id": int64(5), "codigo": "fichas"}},
+			"rol_modulo_permisos": grants,
+		}
+	}
+
+	casos := []struct {
+		nombre    string
+		tablas    map[string][]map[string]driver.Value
+		req       models.AuthorizationRequest
+		permitido bool
+		motivo    string
+	}{
+		{
+			nombre:    "permiso del rol",
+			tablas:    base(models.EstadoActivo, grant(1, models.EfectoPermitir, models.AlcanceGlobal)),
+			req:       models.AuthorizationRequest{IdUsuario: 1, ModuloID: 5, Permiso: "W"},
+			permitido: true,
+			motivo:    "permiso concedido",
+		},
+		{
+			nombre: "la denegación prevalece",
+			tablas: base(models.EstadoActivo,
+				grant(1, models.EfectoPermitir, models.AlcanceGlobal),
+				grant(2, models.EfectoDenegar, models.AlcanceGlobal)),
+			req:    models.AuthorizationRequest{IdUsuario: 1, ModuloID: 5, Permiso: "W"},
+			motivo: "permiso denegado explícitamente",
+		},
+		{
+			nombre: "sede del recurso fuera del alcance",
+			tablas: func() map[string][]map[string]driver.Value {
+				tablas := base(models.EstadoActivo, grant(1, models.EfectoPermitir, models.AlcanceSede))
+				tablas["rol_modulo_permiso_alcances"] = []map[string]driver.Value{{"id": int64(1), "id_rol_modulo_permiso": int64(1), "valor": "Norte"}}
+				return tablas
+			}(),
+			req:    models.AuthorizationRequest{IdUsuario: 1, ModuloID: 5, Permiso: "W", Sede: "Sur"},
+			motivo: "el usuario no tiene el permiso sobre este recurso",
+		},
+		{
+			nombre: "elevación temporal",
+			tablas: func() map[string][]map[string]driver.Value {
+				tablas := base(models.EstadoActivo)
+				tablas["solicitudes_elevacion"] = []map[string]driver.Value{{"id": int64(7), "id_usuario": int64(1), "id_modulo": int64(5),
+					"id_permiso_tipo": int64(2), "estado": models.ElevacionAprobada, "fecha_expiracion": now.Add(time.Hour)}}
+				tablas["permiso_tipos"] = []map[string]driver.Value{{"id": int64(2), "codigo": "W"}}
+				return tablas
+			}(),
+			req:       models.AuthorizationRequest{IdUsuario: 1, ModuloCodigo: "fichas", Permiso: "w"},
+			permitido: true,
+			motivo:    "permiso concedido por elevación temporal #7",
+		},
+		{
+			nombre: "cuenta suspendida",
+			tablas: base(models.EstadoSuspendido, grant(1, models.EfectoPermitir, models.AlcanceGlobal)),
+			req:    models.AuthorizationRequest{IdUsuario: 1, ModuloID: 5, Permiso: "W"},
+			motivo: "la cuenta del usuario está suspendido",
+		},
+	}
+
+	for _, c := range casos {
+		repo := NewAuthorizationRepository(abrirBaseFalsa(t, c.tablas))
+		resp, err := repo.Check(c.req)
+		if err != nil {
+			t.Errorf("%s: error inesperado: %v", c.nombre, err)
+			continue
+		}
+		if resp.Permitido != c.permitido || resp.Motivo != c.motivo {
+			t.Errorf("%s: se obtuvo %v (%s), se esperaba %v (%s)", c.nombre, resp.Permitido, resp.Motivo, c.permitido, c.motivo)
+		}
+	}
+}
+
+func TestGrantsEfectivosDescartaEliminados(t *testing.T) {
+	now := time.Now()
+	antes := now.Add(-time.Hour)
+	despues := now.Add(time.Hour)
+
+	activo := &models.Module{ID: 1, Nombre: "Ventas"}
+	eliminado := &models.Module{ID: 2, Nombre: "Compras", FechaEliminacion: &antes}
+	leer := &models.PermisoTipo{ID: 1, Codigo: "leer"}
+	retirado := &models.PermisoTipo{ID: 2, Codigo: "exportar", FechaEliminacion: &antes}
+
+	grants := []models.RolModuloPermiso{
+		{ID: 1, Modulo: activo, PermisoTipo: leer},
+		{ID: 2, Modulo: activo, PermisoTipo: leer, FechaEliminacion: &antes},
+		{ID: 3, Modulo: eliminado, PermisoTipo: leer},
+		{ID: 4, Modulo: activo, PermisoTipo: retirado},
+		{ID: 5, Modulo: nil, PermisoTipo: leer},
+		{ID: 6, Modulo: activo, PermisoTipo: leer, ValidoDesde: &despues},
+		{ID: 7, Modulo: activo, PermisoTipo: leer, ValidoHasta: &antes},
+	}
+
+	efectivos := grantsEfectivos(grants, &now)
+	if len(efectivos) != 1 || efectivos[0].ID != 1 {
+		t.Fatalf("se esperaba solo la asignación 1, se obtuvo %v", ids(efectivos))
+	}
+
+	// Sin instante de referencia se conservan las asignaciones no vigentes
+	efectivos = grantsEfectivos(grants, nil)
+	if got := ids(efectivos); len(got) != 3 || got[0] != 1 || got[1] != 6 || got[2] != 7 {
+		t.Fatalf("se esperaban las asignaciones 1, 6 y 7, se obtuvo %v", got)
+	}
+}
+
+func TestGrupoPermisosEfectivosDescartaEliminados(t *testing.T) {
+	antes := time.Now().Add(-time.Hour)
+	grupoPermisos := []models.GrupoModuloPermiso{
+		{ID: 1, Modulo: models.Module{ID: 1}, PermisoTipo: models.PermisoTipo{ID: 1}},
+		{ID: 2, Modulo: models.Module{ID: 2, FechaEliminacion: &antes}, PermisoTipo: models.PermisoTipo{ID: 1}},
+		{ID: 3, Modulo: models.Module{ID: 1}, PermisoTipo: models.PermisoTipo{ID: 2, FechaEliminacion: &antes}},
+		{ID: 4, Modulo: models.Module{ID: 1}, PermisoTipo: models.PermisoTipo{ID: 1}, FechaEliminacion: &antes},
+	}
+
+	efectivos := grupoPermisosEfectivos(grupoPermisos)
+	if len(efectivos) != 1 || efectivos[0].ID != 1 {
+		t.Fatalf("se esperaba solo el permiso de grupo 1, se obtuvieron %d", len(efectivos))
+	}
+}
+
+func TestPermisosPorModuloIgnoraModulosEliminados(t *testing.T) {
+	antes := time.Now().Add(-time.Hour)
+	permisos := make(permisosPorModulo)
+	origen := models.PermisoOrigen{Codigo: "leer", Efecto: models.EfectoPermitir, Alcance: models.AlcanceGlobal}
+
+	permisos.agregar(models.Module{ID: 1, Nombre: "Ventas"}, origen)
+	permisos.agregar(models.Module{ID: 2, Nombre: "Compras", FechaEliminacion: &antes}, origen)
+
+	if !permisos.permitido(1, "leer") {
+		t.Fatal("el permiso sobre el módulo activo debería estar concedido")
+	}
+	if permisos.permitido(2, "leer") {
+		t.Fatal("el permiso sobre el módulo eliminado no debería estar concedido")
+	}
+	if lista := permisos.lista(); len(lista) != 1 || lista[0].ID != 1 {
+		t.Fatalf("se esperaba solo el módulo 1 en el listado, se obtuvieron %d módulos", len(lista))
+	}
+}
+
+func ids(grants []models.RolModuloPermiso) []int {
+	result := make([]int, len(grants))
+	for i, grant := range grants {
+		result[i] = grant.ID
+	}
+	return result
+}
@@ -2,6 +2,8 @@ package repository
 
 import (
 	"auth-service/internal/models"
+	"context"
+	"log"
 	"time"
 
 	"gorm.io/gorm"
@@ -15,6 +17,12 @@ func NewRolModuloPermisoRepository(db *gorm.DB) *RolModuloPermisoRepository {
 	return &RolModuloPermisoRepository{db: db}
 }
 
+// WithContext devuelve una copia del repositorio cuyas consultas usan el
+// contexto indicado, incluida la organización de la petición
+func (r *RolModuloPermisoRepository) WithContext(ctx context.Context) *RolModuloPermisoRepository {
+	return &RolModuloPermisoRepository{db: r.db.WithContext(ctx)}
+}
+
 func (r *RolModuloPermisoRepository) Create(rolModuloPermiso *models.RolModuloPermiso) error {
 	return r.db.Create(rolModuloPermiso).Error
 }
@@ -65,3 +73,114 @@ func (r *RolModuloPermisoRepository) DeleteByRoleID(roleID int) error {
 		Where("id_rol = ? AND fecha_eliminacion IS NULL", roleID).
 		Update("fecha_eliminacion", now).Error
 }
+
+// ExpireGrants marca como eliminadas las asignaciones cuya validez terminó
+// y devuelve cuántas se eliminaron
+func (r *RolModuloPermisoRepository) ExpireGrants(now time.Time) (int, error) {
+	var expired []models.RolModuloPermiso
+	if err := r.db.Where("fecha_eliminacion IS NULL AND valido_hasta IS NOT NULL AND valido_hasta <= ?", now).
+		Find(&expired).Error; err != nil {
+		return 0, err
+	}
+	if len(expired) == 0 {
+		return 0, nil
+	}
+
+	ids := make([]int, len(expired))
+	for i, rmp := range expired {
+		ids[i] = rmp.ID
+	}
+
+	if err := r.db.Model(&models.RolModuloPermiso{}).
+		Where("id IN ?", ids).
+		Update("fecha_eliminacion", now).Error; err != nil {
+		return 0, err
+	}
+
+	for _, rmp := range expired {
+		log.Printf("Permiso vencido eliminado: id=%d rol=%d modulo=%s permiso=%s valido_hasta=%s",
+			rmp.ID, rmp.IdRol, idOTodos(rmp.IdModulo), idOTodos(rmp.IdPermisoTipo), rmp.ValidoHasta.Format(time.RFC3339))
+	}
+
+	return len(expired), nil
+}
+
+// GetInconsistentGrants devuelve las asignaciones activas cuyo módulo está
+// eliminado o no ofrece el tipo de permiso asignado. Las asignaciones a todos
+// los módulos nunca son inconsistentes, ya que solo se expanden a los módulos
+// activos y a sus permisos habilitados.
+func (r *RolModuloPermisoRepository) GetInconsistentGrants() ([]models.AsignacionInconsistente, error) {
+	var grants []models.RolModuloPermiso
+	if err := r.db.Where("fecha_eliminacion IS NULL AND id_modulo IS NOT NULL").
+		Preload("Role").
+		Preload("Modulo").
+		Preload("PermisoTipo").
+		Order("id").
+		Find(&grants).Error; err != nil {
+		return nil, err
+	}
+
+	habilitados, err := permisosHabilitados(r.db)
+	if err != nil {
+		return nil, err
+	}
+
+	inconsistentes := []models.AsignacionInconsistente{}
+	for _, grant := range grants {
+		asignacion := models.AsignacionInconsistente{
+			IdRolModuloPermiso: grant.ID,
+			IdRol:              grant.IdRol,
+			Rol:                grant.Role.Nombre,
+			IdModulo:           *grant.IdModulo,
+			IdPermisoTipo:      grant.IdPermisoTipo,
+		}
+		if grant.Modulo != nil {
+			asignacion.Modulo = grant.Modulo.Nombre
+		}
+		if grant.PermisoTipo != nil {
+			asignacion.Codigo = grant.PermisoTipo.Codigo
+		}
+
+		switch {
+		case grant.Modulo == nil || grant.Modulo.FechaEliminacion != nil:
+			asignacion.Motivo = models.InconsistenciaModuloEliminado
+		case grant.IdPermisoTipo != nil && !habilitados[*grant.IdModulo][*grant.IdPermisoTipo]:
+			asignacion.Motivo = models.InconsistenciaPermisoNoHabilitado
+		default:
+			continue
+		}
+		inconsistentes = append(inconsistentes, asignacion)
+	}
+
+	return inconsistentes, nil
+}
+
+// RepairInconsistentGrants marca como eliminadas las asignaciones
+// inconsistentes y las devuelve
+func (r *RolModuloPermisoRepository) RepairInconsistentGrants(now time.Time) ([]models.AsignacionInconsistente, error) {
+	inconsistentes, err := r.GetInconsistentGrants()
+	if err != nil {
+		return nil, err
+	}
+	if len(inconsistentes) == 0 {
+		return inconsistentes, nil
+	}
+
+	ids := make([]int, len(inconsistentes))
+	for i, a := range inconsistentes {
+		ids[i] = a.IdRolModuloPermiso
+	}
+
+	if err := r.db.Model(&models.RolModuloPermiso{}).
+		Where("id IN ?", ids).
+		Update("fecha_eliminacion", now).Error; err != nil {
+		return nil, err
+	}
+
+	for _, a := range inconsistentes {
+		log.Printf("Permiso inconsistente eliminado: id=%d rol=%d modulo=%d permiso=%s motivo=%s",
+			a.IdRolModuloPermiso, a.IdRol, a.IdModulo, idOTodos(a.IdPermisoTipo), a.Motivo)
+	}
+
+	return inconsistentes, nil
+}
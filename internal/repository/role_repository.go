@@ -2,11 +2,26 @@ package repository
 
 import (
 	"auth-service/internal/models"
+	"context"
 	"fmt"
+	"strings"
+	"time"
 
 	"gorm.io/gorm"
 )
 
+// RolEnUsoError indica que un rol no se puede eliminar porque aún lo tienen
+// usuarios o grupos y no se indicó un rol al que reasignarlos
+type RolEnUsoError struct {
+	IdRol    int
+	Usuarios int64
+	Grupos   int64
+}
+
+func (e *RolEnUsoError) Error() string {
+	return fmt.Sprintf("el rol %d está asignado a %d usuarios y %d grupos", e.IdRol, e.Usuarios, e.Grupos)
+}
+
 type RoleRepository struct {
 	db *gorm.DB
 }
@@ -15,6 +30,12 @@ func NewRoleRepository(db *gorm.DB) *RoleRepository {
 	return &RoleRepository{db: db}
 }
 
+// WithContext devuelve una copia del repositorio cuyas consultas usan el
+// contexto indicado, incluida la organización de la petición
+func (r *RoleRepository) WithContext(ctx context.Context) *RoleRepository {
+	return &RoleRepository{db: r.db.WithContext(ctx)}
+}
+
 func (r *RoleRepository) Create(role *models.Role) error {
 	var exists bool
 	if err := r.db.Model(&models.Role{}).
@@ -33,13 +54,13 @@ func (r *RoleRepository) Create(role *models.Role) error {
 
 func (r *RoleRepository) GetAll() ([]models.Role, error) {
 	var roles []models.Role
-	err := r.db.Find(&roles).Error
+	err := r.db.Where("fecha_eliminacion IS NULL").Find(&roles).Error
 	return roles, err
 }
 
 func (r *RoleRepository) GetByID(id int) (*models.Role, error) {
 	var role models.Role
-	err := r.db.First(&role, id).Error
+	err := r.db.Where("fecha_eliminacion IS NULL").First(&role, id).Error
 	if err != nil {
 		return nil, err
 	}
@@ -47,118 +68,427 @@ func (r *RoleRepository) GetByID(id int) (*models.Role, error) {
 }
 
 func (r *RoleRepository) Update(role *models.Role) error {
-	return r.db.Save(role).Error
+	var exists bool
+	if err := r.db.Model(&models.Role{}).
+		Where("LOWER(nombre) = LOWER(?) AND id != ?", role.Nombre, role.ID).
+		Select("count(*) > 0").
+		Scan(&exists).Error; err != nil {
+		return err
+	}
+
+	if exists {
+		return fmt.Errorf("ya existe un rol con este nombre")
+	}
+
+	role.FechaActualizacion = time.Now()
+	return r.db.Model(role).Updates(map[string]interface{}{
+		"nombre":              role.Nombre,
+		"descripcion":         role.Descripcion,
+		"fecha_actualizacion": role.FechaActualizacion,
+	}).Error
 }
 
-func (r *RoleRepository) Delete(id int) error {
+// Delete marca el rol como eliminado junto con sus permisos de módulo y de
+// recurso, registrando la operación en un lote de eliminación. Si aún hay
+// usuarios o grupos con el rol devuelve un *RolEnUsoError, salvo que se
+// indique reasignarA: en ese caso se pasan a ese rol en la misma transacción.
+// Las relaciones de herencia se conservan para la restauración, pero dejan de
+// aplicarse mientras el rol esté eliminado.
+func (r *RoleRepository) Delete(id int, reasignarA *int) error {
 	return r.db.Transaction(func(tx *gorm.DB) error {
-		// Primero eliminamos todos los permisos asociados al rol
-		if err := tx.Where("id_rol = ?", id).Delete(&models.RolModuloPermiso{}).Error; err != nil {
+		var role models.Role
+		if err := tx.Where("fecha_eliminacion IS NULL").First(&role, id).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return fmt.Errorf("rol no encontrado o ya está eliminado")
+			}
+			return err
+		}
+
+		var userIDs, grupoIDs []int
+		if err := tx.Model(&models.User{}).Where("id_rol = ? AND fecha_eliminacion IS NULL", id).Pluck("id", &userIDs).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&models.GrupoRol{}).Where("id_rol = ?", id).Pluck("id_grupo", &grupoIDs).Error; err != nil {
+			return err
+		}
+
+		if len(userIDs) > 0 || len(grupoIDs) > 0 {
+			if reasignarA == nil {
+				return &RolEnUsoError{IdRol: id, Usuarios: int64(len(userIDs)), Grupos: int64(len(grupoIDs))}
+			}
+			if err := reassignRole(tx, id, *reasignarA, userIDs, grupoIDs); err != nil {
+				return err
+			}
+		}
+
+		lote := &models.LoteEliminacion{
+			Entidad:          models.EntidadRol,
+			IdEntidad:        id,
+			FechaEliminacion: time.Now(),
+		}
+		if err := tx.Create(lote).Error; err != nil {
+			return err
+		}
+		marca := map[string]interface{}{
+			"fecha_eliminacion":   lote.FechaEliminacion,
+			"id_lote_eliminacion": lote.ID,
+		}
+
+		// Marcar como eliminados los permisos activos del rol
+		if err := tx.Model(&models.RolModuloPermiso{}).
+			Where("id_rol = ? AND fecha_eliminacion IS NULL", id).
+			Updates(marca).Error; err != nil {
 			return err
 		}
-		// Luego eliminamos el rol
-		return tx.Delete(&models.Role{}, id).Error
+		if err := tx.Model(&models.RecursoPermiso{}).
+			Where("id_rol = ? AND fecha_eliminacion IS NULL", id).
+			Updates(marca).Error; err != nil {
+			return err
+		}
+
+		// Luego marcamos el rol
+		return tx.Model(&role).Updates(marca).Error
 	})
 }
 
-func (r *RoleRepository) AssignModulePermission(roleID, moduleID int, permisoTipoIDs []int) error {
+// Restore restaura un rol eliminado y exactamente los permisos que se
+// marcaron en su lote de eliminación
+func (r *RoleRepository) Restore(id int) error {
 	return r.db.Transaction(func(tx *gorm.DB) error {
-		// Verificar rol
 		var role models.Role
-		if err := tx.First(&role, roleID).Error; err != nil {
+		if err := tx.First(&role, id).Error; err != nil {
+			return fmt.Errorf("rol no encontrado")
+		}
+
+		if role.FechaEliminacion == nil {
+			return fmt.Errorf("el rol no está eliminado")
+		}
+
+		restaurar := map[string]interface{}{
+			"fecha_eliminacion":   nil,
+			"id_lote_eliminacion": nil,
+		}
+		if role.IdLoteEliminacion != nil {
+			if err := tx.Model(&models.RolModuloPermiso{}).
+				Where("id_lote_eliminacion = ?", *role.IdLoteEliminacion).
+				Updates(restaurar).Error; err != nil {
+				return err
+			}
+			if err := tx.Model(&models.RecursoPermiso{}).
+				Where("id_lote_eliminacion = ?", *role.IdLoteEliminacion).
+				Updates(restaurar).Error; err != nil {
+				return err
+			}
+		}
+
+		if err := tx.Model(&role).Updates(restaurar).Error; err != nil {
+			return err
+		}
+
+		// Los roles que heredan del restaurado recuperan sus permisos
+		return checkRoleTreeSoD(tx, id)
+	})
+}
+
+func (r *RoleRepository) GetDeletedRoles() ([]models.Role, error) {
+	var roles []models.Role
+	err := r.db.Where("fecha_eliminacion IS NOT NULL").
+		Order("fecha_eliminacion DESC").
+		Find(&roles).Error
+	return roles, err
+}
+
+// reassignRole pasa los usuarios y grupos indicados del rol origen al rol
+// destino y valida la separación de funciones con el nuevo rol
+func reassignRole(tx *gorm.DB, origenID, destinoID int, userIDs, grupoIDs []int) error {
+	if destinoID == origenID {
+		return fmt.Errorf("el rol de reasignación debe ser distinto del rol eliminado")
+	}
+	if err := tx.Where("fecha_eliminacion IS NULL").First(&models.Role{}, destinoID).Error; err != nil {
+		return fmt.Errorf("rol de reasignación no encontrado: %v", err)
+	}
+
+	if len(userIDs) > 0 {
+		if err := tx.Model(&models.User{}).Where("id IN ?", userIDs).Update("id_rol", destinoID).Error; err != nil {
+			return err
+		}
+	}
+
+	if len(grupoIDs) > 0 {
+		// Los grupos que ya tienen el rol destino solo pierden el de origen
+		if err := tx.Where("id_rol = ? AND id_grupo IN (?)", origenID,
+			tx.Model(&models.GrupoRol{}).Where("id_rol = ?", destinoID).Select("id_grupo")).
+			Delete(&models.GrupoRol{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&models.GrupoRol{}).Where("id_rol = ?", origenID).Update("id_rol", destinoID).Error; err != nil {
+			return err
+		}
+	}
+
+	for _, userID := range userIDs {
+		if err := checkUserSoD(tx, userID, destinoID); err != nil {
+			return err
+		}
+	}
+	for _, grupoID := range grupoIDs {
+		if err := checkGroupMembersSoD(tx, grupoID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Clone crea un rol con los permisos de módulo vigentes del rol de origen,
+// incluidos sus alcances y ventanas de validez, y con sus mismos roles padre
+func (r *RoleRepository) Clone(sourceID int, role *models.Role) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var source models.Role
+		if err := tx.Where("fecha_eliminacion IS NULL").First(&source, sourceID).Error; err != nil {
 			return fmt.Errorf("rol no encontrado: %v", err)
 		}
 
-		// Verificar módulo
-		var module models.Module
-		if err := tx.First(&module, moduleID).Error; err != nil {
-			return fmt.Errorf("módulo no encontrado: %v", err)
+		var exists bool
+		if err := tx.Model(&models.Role{}).
+			Where("LOWER(nombre) = LOWER(?)", role.Nombre).
+			Select("count(*) > 0").
+			Scan(&exists).Error; err != nil {
+			return err
+		}
+		if exists {
+			return fmt.Errorf("ya existe un rol con este nombre")
+		}
+
+		if err := tx.Create(role).Error; err != nil {
+			return err
 		}
 
-		// Verificar permisos
-		var count int64
-		if err := tx.Model(&models.PermisoTipo{}).Where("id IN ?", permisoTipoIDs).Count(&count).Error; err != nil {
+		var grants []models.RolModuloPermiso
+		if err := tx.Where("id_rol = ? AND fecha_eliminacion IS NULL", sourceID).
+			Preload("Valores").
+			Find(&grants).Error; err != nil {
 			return err
 		}
-		if int(count) != len(permisoTipoIDs) {
-			return fmt.Errorf("algunos permisos no existen")
+		for _, grant := range grants {
+			copia := &models.RolModuloPermiso{
+				IdRol:             role.ID,
+				IdModulo:          grant.IdModulo,
+				IdPermisoTipo:     grant.IdPermisoTipo,
+				Efecto:            grant.Efecto,
+				Alcance:           grant.Alcance,
+				ValidoDesde:       grant.ValidoDesde,
+				ValidoHasta:       grant.ValidoHasta,
+				IncluyeSubmodulos: grant.IncluyeSubmodulos,
+			}
+			for _, v := range grant.Valores {
+				copia.Valores = append(copia.Valores, models.RolModuloPermisoAlcance{Valor: v.Valor})
+			}
+			if err := tx.Create(copia).Error; err != nil {
+				return err
+			}
 		}
 
-		// Eliminar permisos existentes
-		if err := tx.Where("id_rol = ? AND id_modulo = ?", roleID, moduleID).
-			Delete(&models.RolModuloPermiso{}).Error; err != nil {
+		var padres []models.RolHerencia
+		if err := tx.Where("id_rol = ?", sourceID).Find(&padres).Error; err != nil {
 			return err
 		}
+		for _, padre := range padres {
+			if err := tx.Create(&models.RolHerencia{IdRol: role.ID, IdRolPadre: padre.IdRolPadre}).Error; err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+func (r *RoleRepository) AssignModulePermission(req models.AssignRolePermissionsRequest) error {
+	roleID, moduleID, permisoTipoIDs := req.RoleID, req.ModuloID, req.PermisoTipoID
+
+	efecto := req.Efecto
+	if efecto == "" {
+		efecto = models.EfectoPermitir
+	}
+	if efecto != models.EfectoPermitir && efecto != models.EfectoDenegar {
+		return fmt.Errorf("efecto inválido: debe ser '%s' o '%s'", models.EfectoPermitir, models.EfectoDenegar)
+	}
+
+	alcance := req.Alcance
+	if alcance == "" {
+		alcance = models.AlcanceGlobal
+	}
+	valores, err := validateAlcance(alcance, req.Valores)
+	if err != nil {
+		return err
+	}
+	if err := validateVentana(req.ValidoDesde, req.ValidoHasta); err != nil {
+		return err
+	}
+
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		// Verificar rol
+		var role models.Role
+		if err := tx.Where("fecha_eliminacion IS NULL").First(&role, roleID).Error; err != nil {
+			return fmt.Errorf("rol no encontrado: %v", err)
+		}
+
+		// Las sedes y regionales del alcance deben estar en el catálogo
+		valores, err := valoresCatalogo(tx, alcance, valores)
+		if err != nil {
+			return err
+		}
+
+		// Verificar módulo, salvo que la asignación sea para todos los módulos
+		var modulo *int
+		if !req.TodosModulos {
+			var module models.Module
+			if err := tx.Where("fecha_eliminacion IS NULL").First(&module, moduleID).Error; err != nil {
+				return fmt.Errorf("módulo no encontrado: %v", err)
+			}
+			modulo = &moduleID
+		}
+
+		// Verificar permisos, salvo que la asignación sea para todos los tipos
+		permisos := []*int{nil}
+		if !req.TodosPermisos {
+			var count int64
+			if err := tx.Model(&models.PermisoTipo{}).Where("id IN ? AND fecha_eliminacion IS NULL", permisoTipoIDs).Count(&count).Error; err != nil {
+				return err
+			}
+			if int(count) != len(permisoTipoIDs) {
+				return fmt.Errorf("algunos permisos no existen")
+			}
+			// El módulo debe ofrecer los permisos asignados
+			if modulo != nil {
+				if err := validatePermisosHabilitados(tx, moduleID, permisoTipoIDs); err != nil {
+					return err
+				}
+			}
+			permisos = make([]*int, len(permisoTipoIDs))
+			for i := range permisoTipoIDs {
+				permisos[i] = &permisoTipoIDs[i]
+			}
+		}
+
+		// Retirar permisos existentes con el mismo efecto y alcance
+		if _, err := retirarGrantsRol(tx, roleID,
+			func(db *gorm.DB) *gorm.DB { return db.Where("efecto = ? AND alcance = ?", efecto, alcance) },
+			mismoValor("id_modulo", modulo)); err != nil {
+			return err
+		}
+
+		// Cada permiso tiene una única asignación por rol y módulo, por lo que
+		// no puede estar permitido y denegado a la vez ni con dos alcances distintos
+		for _, permiso := range permisos {
+			if _, err := retirarGrantsRol(tx, roleID,
+				mismoValor("id_modulo", modulo), mismoValor("id_permiso_tipo", permiso)); err != nil {
+				return err
+			}
+		}
 
 		// Crear nuevos permisos
-		for _, permisoID := range permisoTipoIDs {
+		for _, permiso := range permisos {
 			rolModuloPermiso := &models.RolModuloPermiso{
 				IdRol:         roleID,
-				IdModulo:      moduleID,
-				IdPermisoTipo: permisoID,
+				IdModulo:      modulo,
+				IdPermisoTipo: permiso,
+				Efecto:        efecto,
+				Alcance:       alcance,
+				ValidoDesde:   req.ValidoDesde,
+				ValidoHasta:   req.ValidoHasta,
+				// Solo una asignación a un módulo concreto puede extenderse a sus submódulos
+				IncluyeSubmodulos: req.IncluyeSubmodulos && modulo != nil,
+			}
+			for _, valor := range valores {
+				rolModuloPermiso.Valores = append(rolModuloPermiso.Valores, models.RolModuloPermisoAlcance{Valor: valor})
 			}
 			if err := tx.Create(rolModuloPermiso).Error; err != nil {
 				return err
 			}
 		}
 
-		return nil
+		// Validar separación de funciones con los permisos resultantes
+		return checkRoleTreeSoD(tx, roleID)
 	})
 }
 
 func (r *RoleRepository) GetRolePermissions(roleID int) ([]models.RolModuloPermisoResponse, error) {
 	var role models.Role
-	if err := r.db.First(&role, roleID).Error; err != nil {
+	if err := r.db.Where("fecha_eliminacion IS NULL").First(&role, roleID).Error; err != nil {
 		return nil, fmt.Errorf("rol no encontrado: %v", err)
 	}
 
-	var permissions []models.RolModuloPermiso
-	err := r.db.Where("id_rol = ? AND fecha_eliminacion IS NULL", roleID).
-		Preload("Role", func(db *gorm.DB) *gorm.DB {
-			return db.Select("id, nombre, descripcion, fecha_creacion, fecha_actualizacion")
-		}).
-		Preload("Modulo").
-		Preload("PermisoTipo").
-		Find(&permissions).Error
+	// Incluir los permisos heredados de los roles padre
+	ancestorIDs, err := roleAncestorIDs(r.db, roleID)
+	if err != nil {
+		return nil, err
+	}
+	roleIDs := append([]int{roleID}, ancestorIDs...)
 
+	// Las asignaciones comodín se muestran como una fila por módulo y permiso
+	permissions, err := activeGrants(r.db, roleIDs, nil, "Role")
 	if err != nil {
 		return nil, err
 	}
 
-	response := make([]models.RolModuloPermisoResponse, len(permissions))
-	for i, p := range permissions {
-		response[i] = models.RolModuloPermisoResponse{
+	response := make([]models.RolModuloPermisoResponse, 0, len(permissions))
+	for _, p := range permissions {
+		response = append(response, models.RolModuloPermisoResponse{
 			ID:            p.ID,
 			IdRol:         p.IdRol,
-			IdModulo:      p.IdModulo,
-			IdPermisoTipo: p.IdPermisoTipo,
+			IdModulo:      *p.IdModulo,
+			IdPermisoTipo: *p.IdPermisoTipo,
+			Efecto:        p.Efecto,
+			Alcance:       p.Alcance,
+			Valores:       p.ValoresAlcance(),
+			ValidoDesde:   p.ValidoDesde,
+			ValidoHasta:   p.ValidoHasta,
 			FechaCreacion: p.FechaCreacion,
 			Role:          p.Role,
 			Modulo: models.ModuleResponse{
 				ID:                 p.Modulo.ID,
+				Codigo:             p.Modulo.Codigo,
 				Nombre:             p.Modulo.Nombre,
 				Descripcion:        p.Modulo.Descripcion,
 				FechaCreacion:      p.Modulo.FechaCreacion,
 				FechaActualizacion: p.Modulo.FechaActualizacion,
 			},
-			PermisoTipo: p.PermisoTipo,
-		}
+			PermisoTipo: *p.PermisoTipo,
+			Heredado:    p.IdRol != roleID,
+			Comodin:     p.Comodin,
+		})
 	}
 
 	return response, nil
 }
 
-func (r *RoleRepository) RemoveModulePermission(roleID, moduleID, permisoTipoID int) error {
-	result := r.db.Where(
-		"id_rol = ? AND id_modulo = ? AND id_permiso_tipo = ?",
-		roleID, moduleID, permisoTipoID,
-	).Delete(&models.RolModuloPermiso{})
+// RemoveModulePermission elimina la asignación de un permiso de un módulo.
+// Un moduleID o permisoTipoID nulo elimina la asignación comodín
+// correspondiente; un permiso concedido solo por un comodín no puede
+// eliminarse por separado.
+func (r *RoleRepository) RemoveModulePermission(roleID int, moduleID, permisoTipoID *int) error {
+	var role models.Role
+	if err := r.db.Where("fecha_eliminacion IS NULL").First(&role, roleID).Error; err != nil {
+		return fmt.Errorf("rol no encontrado: %v", err)
+	}
 
-	if result.Error != nil {
-		return result.Error
+	retirados, err := retirarGrantsRol(r.db, roleID,
+		mismoValor("id_modulo", moduleID), mismoValor("id_permiso_tipo", permisoTipoID))
+	if err != nil {
+		return err
 	}
 
-	if result.RowsAffected == 0 {
+	if retirados == 0 {
+		if moduleID != nil && permisoTipoID != nil {
+			var comodin models.RolModuloPermiso
+			err := r.db.Where("id_rol = ? AND fecha_eliminacion IS NULL", roleID).
+				Where("(id_modulo IS NULL OR id_modulo = ?) AND (id_permiso_tipo IS NULL OR id_permiso_tipo = ?)",
+					*moduleID, *permisoTipoID).
+				First(&comodin).Error
+			if err == nil {
+				return fmt.Errorf("el permiso proviene de la asignación comodín %d: elimínela o asigne una denegación", comodin.ID)
+			}
+		}
 		return fmt.Errorf("no se encontró el permiso especificado")
 	}
 
@@ -166,24 +496,203 @@ func (r *RoleRepository) RemoveModulePermission(roleID, moduleID, permisoTipoID
 }
 
 func (r *RoleRepository) RemoveModuleFromRole(roleID, moduleID int) error {
-	result := r.db.Where(
-		"id_rol = ? AND id_modulo = ?",
-		roleID, moduleID,
-	).Delete(&models.RolModuloPermiso{})
+	var role models.Role
+	if err := r.db.Where("fecha_eliminacion IS NULL").First(&role, roleID).Error; err != nil {
+		return fmt.Errorf("rol no encontrado: %v", err)
+	}
 
-	if result.Error != nil {
-		return result.Error
+	retirados, err := retirarGrantsRol(r.db, roleID, mismoValor("id_modulo", &moduleID))
+	if err != nil {
+		return err
 	}
 
-	if result.RowsAffected == 0 {
+	if retirados == 0 {
 		return fmt.Errorf("no se encontraron permisos para el módulo especificado")
 	}
 
 	return nil
 }
 
+// retirarGrantsRol marca como eliminadas las asignaciones activas del rol que
+// cumplen los filtros indicados. No se asocian a ningún lote, por lo que la
+// restauración del rol no las recupera.
+func retirarGrantsRol(db *gorm.DB, roleID int, filtros ...func(*gorm.DB) *gorm.DB) (int64, error) {
+	result := db.Model(&models.RolModuloPermiso{}).
+		Where("id_rol = ? AND fecha_eliminacion IS NULL", roleID).
+		Scopes(filtros...).
+		Update("fecha_eliminacion", time.Now())
+	return result.RowsAffected, result.Error
+}
+
 func (r *RoleRepository) GetUsersByRoleID(roleID int) ([]models.User, error) {
 	var users []models.User
-	err := r.db.Where("id_rol = ?", roleID).Find(&users).Error
+	err := r.db.Where("id_rol = ? AND fecha_eliminacion IS NULL", roleID).Find(&users).Error
 	return users, err
 }
+
+func (r *RoleRepository) AddParent(roleID, parentID int) error {
+	if roleID == parentID {
+		return fmt.Errorf("un rol no puede heredar de sí mismo")
+	}
+
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		// Verificar rol y rol padre
+		var role models.Role
+		if err := tx.Where("fecha_eliminacion IS NULL").First(&role, roleID).Error; err != nil {
+			return fmt.Errorf("rol no encontrado: %v", err)
+		}
+		var parent models.Role
+		if err := tx.Where("fecha_eliminacion IS NULL").First(&parent, parentID).Error; err != nil {
+			return fmt.Errorf("rol padre no encontrado: %v", err)
+		}
+
+		var exists bool
+		if err := tx.Model(&models.RolHerencia{}).
+			Where("id_rol = ? AND id_rol_padre = ?", roleID, parentID).
+			Select("count(*) > 0").
+			Scan(&exists).Error; err != nil {
+			return err
+		}
+		if exists {
+			return fmt.Errorf("el rol ya hereda de este rol padre")
+		}
+
+		// Rechazar ciclos: el rol no puede ser ancestro de su nuevo padre
+		ancestorIDs, err := roleAncestorIDs(tx, parentID)
+		if err != nil {
+			return err
+		}
+		for _, id := range ancestorIDs {
+			if id == roleID {
+				return fmt.Errorf("la herencia generaría un ciclo entre roles")
+			}
+		}
+
+		if err := tx.Create(&models.RolHerencia{
+			IdRol:      roleID,
+			IdRolPadre: parentID,
+		}).Error; err != nil {
+			return err
+		}
+
+		// Heredar del nuevo padre no debe violar la separación de funciones
+		return checkRoleTreeSoD(tx, roleID)
+	})
+}
+
+func (r *RoleRepository) RemoveParent(roleID, parentID int) error {
+	result := r.db.Where("id_rol = ? AND id_rol_padre = ?", roleID, parentID).
+		Delete(&models.RolHerencia{})
+
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("el rol no hereda del rol especificado")
+	}
+
+	return nil
+}
+
+func (r *RoleRepository) GetParents(roleID int) ([]models.Role, error) {
+	var role models.Role
+	if err := r.db.Where("fecha_eliminacion IS NULL").First(&role, roleID).Error; err != nil {
+		return nil, fmt.Errorf("rol no encontrado: %v", err)
+	}
+
+	var parents []models.Role
+	err := r.db.Joins("JOIN rol_herencias ON rol_herencias.id_rol_padre = roles.id").
+		Where("rol_herencias.id_rol = ? AND roles.fecha_eliminacion IS NULL", roleID).
+		Find(&parents).Error
+	return parents, err
+}
+
+// validateAlcance comprueba que los valores sean coherentes con el alcance
+// y los devuelve sin espacios sobrantes
+func validateAlcance(alcance string, valores []string) ([]string, error) {
+	limpios := make([]string, 0, len(valores))
+	for _, v := range valores {
+		if v = strings.TrimSpace(v); v != "" {
+			limpios = append(limpios, v)
+		}
+	}
+
+	switch alcance {
+	case models.AlcanceSede, models.AlcanceRegional:
+		if len(limpios) == 0 {
+			return nil, fmt.Errorf("el alcance '%s' requiere al menos un valor", alcance)
+		}
+	case models.AlcanceGlobal, models.AlcanceMismaSede, models.AlcanceMismaRegional:
+		if len(limpios) > 0 {
+			return nil, fmt.Errorf("el alcance '%s' no admite valores", alcance)
+		}
+	default:
+		return nil, fmt.Errorf("alcance inválido: %s", alcance)
+	}
+
+	return limpios, nil
+}
+
+// roleDescendantIDs devuelve todos los roles que heredan (directa o indirectamente) de un rol
+func roleDescendantIDs(db *gorm.DB, roleID int) ([]int, error) {
+	visited := map[int]bool{roleID: true}
+	descendants := make([]int, 0)
+	pending := []int{roleID}
+
+	for len(pending) > 0 {
+		var childIDs []int
+		if err := db.Model(&models.RolHerencia{}).
+			Where("id_rol_padre IN ?", pending).
+			Where("id_rol IN (?)", rolesActivos(db)).
+			Pluck("id_rol", &childIDs).Error; err != nil {
+			return nil, err
+		}
+
+		pending = pending[:0]
+		for _, id := range childIDs {
+			if visited[id] {
+				continue
+			}
+			visited[id] = true
+			descendants = append(descendants, id)
+			pending = append(pending, id)
+		}
+	}
+
+	return descendants, nil
+}
+
+// roleAncestorIDs devuelve todos los ancestros (directos e indirectos) de un rol
+func roleAncestorIDs(db *gorm.DB, roleID int) ([]int, error) {
+	visited := map[int]bool{roleID: true}
+	ancestors := make([]int, 0)
+	pending := []int{roleID}
+
+	for len(pending) > 0 {
+		var parentIDs []int
+		if err := db.Model(&models.RolHerencia{}).
+			Where("id_rol IN ?", pending).
+			Where("id_rol_padre IN (?)", rolesActivos(db)).
+			Pluck("id_rol_padre", &parentIDs).Error; err != nil {
+			return nil, err
+		}
+
+		pending = pending[:0]
+		for _, id := range parentIDs {
+			if visited[id] {
+				continue
+			}
+			visited[id] = true
+			ancestors = append(ancestors, id)
+			pending = append(pending, id)
+		}
+	}
+
+	return ancestors, nil
+}
+
+// rolesActivos es una subconsulta con los ids de los roles no eliminados
+func rolesActivos(db *gorm.DB) *gorm.DB {
+	return db.Model(&models.Role{}).Where("fecha_eliminacion IS NULL").Select("id")
+}
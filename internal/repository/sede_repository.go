@@ -0,0 +1,317 @@
+package repository
+
+import (
+	"auth-service/internal/models"
+	"context"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type SedeRepository struct {
+	db *gorm.DB
+}
+
+func NewSedeRepository(db *gorm.DB) *SedeRepository {
+	return &SedeRepository{db: db}
+}
+
+// WithContext devuelve una copia del repositorio cuyas consultas usan el
+// contexto indicado, incluida la organización de la petición
+func (r *SedeRepository) WithContext(ctx context.Context) *SedeRepository {
+	return &SedeRepository{db: r.db.WithContext(ctx)}
+}
+
+func (r *SedeRepository) Create(sede *models.Sede) error {
+	sede.Nombre = models.NombreCatalogo(sede.Nombre)
+	sede.Clave = models.ClaveCatalogo(sede.Nombre)
+	if sede.Clave == "" {
+		return fmt.Errorf("el nombre de la sede es obligatorio")
+	}
+	if err := r.db.First(&models.Regional{}, sede.IdRegional).Error; err != nil {
+		return fmt.Errorf("regional no encontrada: %v", err)
+	}
+	if err := sedeDisponible(r.db, sede.IdRegional, sede.Clave, 0); err != nil {
+		return err
+	}
+
+	sede.FechaCreacion = time.Now()
+	sede.FechaActualizacion = time.Now()
+	return r.db.Create(sede).Error
+}
+
+// GetAll devuelve las sedes con su regional, opcionalmente solo las de una regional
+func (r *SedeRepository) GetAll(regionalID int) ([]models.Sede, error) {
+	var sedes []models.Sede
+	query := r.db.Preload("Regional").Order("nombre")
+	if regionalID != 0 {
+		query = query.Where("id_regional = ?", regionalID)
+	}
+	err := query.Find(&sedes).Error
+	return sedes, err
+}
+
+func (r *SedeRepository) GetByID(id int) (*models.Sede, error) {
+	var sede models.Sede
+	if err := r.db.Preload("Regional").First(&sede, id).Error; err != nil {
+		return nil, fmt.Errorf("sede no encontrada: %v", err)
+	}
+	return &sede, nil
+}
+
+// Update renombra la sede o la cambia de regional, y actualiza la sede y la
+// regional guardadas en sus usuarios y el nombre en los alcances de las
+// asignaciones
+func (r *SedeRepository) Update(id int, req models.CreateSedeRequest) (*models.Sede, error) {
+	sede, err := r.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	nombre := models.NombreCatalogo(req.Nombre)
+	clave := models.ClaveCatalogo(nombre)
+	if clave == "" {
+		return nil, fmt.Errorf("el nombre de la sede es obligatorio")
+	}
+
+	err = r.db.Transaction(func(tx *gorm.DB) error {
+		var regional models.Regional
+		if err := tx.First(&regional, req.IdRegional).Error; err != nil {
+			return fmt.Errorf("regional no encontrada: %v", err)
+		}
+		if err := sedeDisponible(tx, regional.ID, clave, id); err != nil {
+			return err
+		}
+		compartido, err := nombreSedeCompartido(tx, sede.Clave, id)
+		if err != nil {
+			return err
+		}
+		if err := renombrarValoresAlcance(tx, models.AlcanceSede, sede.Nombre, nombre, compartido); err != nil {
+			return err
+		}
+		if err := tx.Model(sede).Updates(map[string]interface{}{
+			"id_regional":         regional.ID,
+			"nombre":              nombre,
+			"clave":               clave,
+			"fecha_actualizacion": time.Now(),
+		}).Error; err != nil {
+			return err
+		}
+		return tx.Model(&models.User{}).Where("id_sede = ?", id).Updates(map[string]interface{}{
+			"sede":        nombre,
+			"id_regional": regional.ID,
+			"regional":    regional.Nombre,
+		}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return r.GetByID(id)
+}
+
+// Delete elimina la sede si ningún usuario ni asignación activa la referencia.
+// Las asignaciones guardan el nombre de la sede, así que solo la referencian
+// si ninguna otra sede de la organización tiene el mismo nombre.
+func (r *SedeRepository) Delete(id int) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var sede models.Sede
+		if err := tx.First(&sede, id).Error; err != nil {
+			return fmt.Errorf("sede no encontrada: %v", err)
+		}
+
+		enUso := &CatalogoEnUsoError{Entidad: "sede"}
+		if err := tx.Model(&models.User{}).Where("id_sede = ?", id).Count(&enUso.Usuarios).Error; err != nil {
+			return err
+		}
+		compartido, err := nombreSedeCompartido(tx, sede.Clave, id)
+		if err != nil {
+			return err
+		}
+		if !compartido {
+			valores, err := valoresAlcanceDe(tx, models.AlcanceSede, sede.Clave, true)
+			if err != nil {
+				return err
+			}
+			enUso.Asignaciones = asignacionesDe(valores)
+		}
+		if enUso.Usuarios > 0 || enUso.Asignaciones > 0 {
+			return enUso
+		}
+
+		return tx.Delete(&models.Sede{}, id).Error
+	})
+}
+
+// sedeDisponible comprueba que ninguna otra sede de la regional tenga el mismo nombre
+func sedeDisponible(db *gorm.DB, regionalID int, clave string, id int) error {
+	var exists bool
+	if err := db.Model(&models.Sede{}).
+		Where("id_regional = ? AND clave = ? AND id != ?", regionalID, clave, id).
+		Select("count(*) > 0").
+		Scan(&exists).Error; err != nil {
+		return err
+	}
+	if exists {
+		return fmt.Errorf("ya existe una sede con este nombre en la regional")
+	}
+	return nil
+}
+
+// nombreSedeCompartido indica si otra sede de la organización, en otra
+// regional, tiene el mismo nombre
+func nombreSedeCompartido(db *gorm.DB, clave string, id int) (bool, error) {
+	var compartido bool
+	err := db.Model(&models.Sede{}).
+		Where("clave = ? AND id != ?", clave, id).
+		Select("count(*) > 0").
+		Scan(&compartido).Error
+	return compartido, err
+}
+
+// valoresAlcanceDe devuelve los valores de los alcances por sede o regional
+// que nombran la entrada del catálogo con la clave indicada; con soloActivas
+// solo los de asignaciones no eliminadas
+func valoresAlcanceDe(db *gorm.DB, alcance, clave string, soloActivas bool) ([]models.RolModuloPermisoAlcance, error) {
+	asignaciones := db.Model(&models.RolModuloPermiso{}).Where("alcance = ?", alcance)
+	if soloActivas {
+		asignaciones = asignaciones.Where("fecha_eliminacion IS NULL")
+	}
+
+	var valores []models.RolModuloPermisoAlcance
+	if err := db.Where("id_rol_modulo_permiso IN (?)", asignaciones.Select("id")).
+		Order("id").
+		Find(&valores).Error; err != nil {
+		return nil, err
+	}
+
+	coincidentes := valores[:0]
+	for _, v := range valores {
+		if models.ClaveCatalogo(v.Valor) == clave {
+			coincidentes = append(coincidentes, v)
+		}
+	}
+	return coincidentes, nil
+}
+
+// asignacionesDe cuenta las asignaciones distintas a las que pertenecen los valores
+func asignacionesDe(valores []models.RolModuloPermisoAlcance) int64 {
+	ids := make(map[int]bool, len(valores))
+	for _, v := range valores {
+		ids[v.IdRolModuloPermiso] = true
+	}
+	return int64(len(ids))
+}
+
+// renombrarValoresAlcance reemplaza, en los alcances por sede o regional, el
+// nombre anterior de la entrada del catálogo por el nuevo, incluidas las
+// asignaciones eliminadas para que una restauración las recupere vigentes. Si
+// otra entrada conserva el nombre anterior (compartido), las asignaciones la
+// siguen cubriendo, así que se mantiene el valor y se agrega el nuevo.
+func renombrarValoresAlcance(tx *gorm.DB, alcance, anterior, nuevo string, compartido bool) error {
+	claveAnterior, claveNueva := models.ClaveCatalogo(anterior), models.ClaveCatalogo(nuevo)
+	if anterior == nuevo || (compartido && claveAnterior == claveNueva) {
+		return nil
+	}
+
+	valores, err := valoresAlcanceDe(tx, alcance, claveAnterior, false)
+	if err != nil {
+		return err
+	}
+
+	for _, v := range valores {
+		// Una asignación que ya incluye el nombre nuevo no lo repite
+		var existentes []string
+		if err := tx.Model(&models.RolModuloPermisoAlcance{}).
+			Where("id_rol_modulo_permiso = ? AND id != ?", v.IdRolModuloPermiso, v.ID).
+			Pluck("valor", &existentes).Error; err != nil {
+			return err
+		}
+		incluido := false
+		for _, existente := range existentes {
+			if models.ClaveCatalogo(existente) == claveNueva {
+				incluido = true
+				break
+			}
+		}
+
+		if compartido {
+			if incluido {
+				continue
+			}
+			if err := tx.Create(&models.RolModuloPermisoAlcance{
+				IdRolModuloPermiso: v.IdRolModuloPermiso,
+				Valor:              nuevo,
+			}).Error; err != nil {
+				return err
+			}
+			continue
+		}
+		if incluido {
+			if err := tx.Delete(&models.RolModuloPermisoAlcance{}, v.ID).Error; err != nil {
+				return err
+			}
+			continue
+		}
+		if err := tx.Model(&models.RolModuloPermisoAlcance{}).
+			Where("id = ?", v.ID).
+			Update("valor", nuevo).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// asignarSede completa la regional del usuario a partir de su sede y guarda
+// los nombres del catálogo
+func asignarSede(db *gorm.DB, user *models.User) error {
+	if user.IdSede == nil {
+		return fmt.Errorf("la sede es obligatoria")
+	}
+	var sede models.Sede
+	if err := db.Preload("Regional").First(&sede, *user.IdSede).Error; err != nil {
+		return fmt.Errorf("sede no encontrada: %v", err)
+	}
+
+	user.Sede = sede.Nombre
+	user.IdRegional = &sede.IdRegional
+	user.Regional = sede.Regional.Nombre
+	return nil
+}
+
+// valoresCatalogo reemplaza los valores de un alcance por sede o regional por
+// los nombres del catálogo, y rechaza los que no están en él
+func valoresCatalogo(db *gorm.DB, alcance string, valores []string) ([]string, error) {
+	var model interface{}
+	switch alcance {
+	case models.AlcanceSede:
+		model = &models.Sede{}
+	case models.AlcanceRegional:
+		model = &models.Regional{}
+	default:
+		return valores, nil
+	}
+
+	nombres := make([]string, len(valores))
+	for i, valor := range valores {
+		var encontrados []string
+		if err := db.Model(model).
+			Where("clave = ?", models.ClaveCatalogo(valor)).
+			Limit(1).
+			Pluck("nombre", &encontrados).Error; err != nil {
+			return nil, err
+		}
+		if len(encontrados) == 0 {
+			return nil, fmt.Errorf("'%s' no está en el catálogo de %s", valor, alcanceCatalogo(alcance))
+		}
+		nombres[i] = encontrados[0]
+	}
+	return nombres, nil
+}
+
+func alcanceCatalogo(alcance string) string {
+	if alcance == models.AlcanceSede {
+		return "sedes"
+	}
+	return "regionales"
+}
@@ -0,0 +1,313 @@
+package repository
+
+import (
+	"auth-service/internal/models"
+	"context"
+	"fmt"
+
+	"gorm.io/gorm"
+)
+
+type SoDRepository struct {
+	db *gorm.DB
+}
+
+func NewSoDRepository(db *gorm.DB) *SoDRepository {
+	return &SoDRepository{db: db}
+}
+
+// WithContext devuelve una copia del repositorio cuyas consultas usan el
+// contexto indicado, incluida la organización de la petición
+func (r *SoDRepository) WithContext(ctx context.Context) *SoDRepository {
+	return &SoDRepository{db: r.db.WithContext(ctx)}
+}
+
+func (r *SoDRepository) Create(restriccion *models.RestriccionSoD) error {
+	switch restriccion.Tipo {
+	case models.SoDTipoRoles:
+		if restriccion.IdRolA == nil || restriccion.IdRolB == nil {
+			return fmt.Errorf("una restricción de roles requiere id_rol_a e id_rol_b")
+		}
+		if *restriccion.IdRolA == *restriccion.IdRolB {
+			return fmt.Errorf("los roles de la restricción deben ser distintos")
+		}
+		var count int64
+		if err := r.db.Model(&models.Role{}).
+			Where("id IN ? AND fecha_eliminacion IS NULL", []int{*restriccion.IdRolA, *restriccion.IdRolB}).
+			Count(&count).Error; err != nil {
+			return err
+		}
+		if count != 2 {
+			return fmt.Errorf("algunos roles no existen")
+		}
+		restriccion.IdModuloA, restriccion.IdPermisoTipoA = nil, nil
+		restriccion.IdModuloB, restriccion.IdPermisoTipoB = nil, nil
+
+	case models.SoDTipoPermisos:
+		if restriccion.IdModuloA == nil || restriccion.IdPermisoTipoA == nil ||
+			restriccion.IdModuloB == nil || restriccion.IdPermisoTipoB == nil {
+			return fmt.Errorf("una restricción de permisos requiere módulo y tipo de permiso para ambos lados")
+		}
+		if *restriccion.IdModuloA == *restriccion.IdModuloB && *restriccion.IdPermisoTipoA == *restriccion.IdPermisoTipoB {
+			return fmt.Errorf("los permisos de la restricción deben ser distintos")
+		}
+		var count int64
+		if err := r.db.Model(&models.Module{}).
+			Where("id IN ?", []int{*restriccion.IdModuloA, *restriccion.IdModuloB}).
+			Count(&count).Error; err != nil {
+			return err
+		}
+		if (*restriccion.IdModuloA == *restriccion.IdModuloB && count != 1) ||
+			(*restriccion.IdModuloA != *restriccion.IdModuloB && count != 2) {
+			return fmt.Errorf("algunos módulos no existen")
+		}
+		if err := r.db.Model(&models.PermisoTipo{}).
+			Where("fecha_eliminacion IS NULL").
+			Where("id IN ?", []int{*restriccion.IdPermisoTipoA, *restriccion.IdPermisoTipoB}).
+			Count(&count).Error; err != nil {
+			return err
+		}
+		if (*restriccion.IdPermisoTipoA == *restriccion.IdPermisoTipoB && count != 1) ||
+			(*restriccion.IdPermisoTipoA != *restriccion.IdPermisoTipoB && count != 2) {
+			return fmt.Errorf("algunos tipos de permisos no existen")
+		}
+		restriccion.IdRolA, restriccion.IdRolB = nil, nil
+
+	default:
+		return fmt.Errorf("tipo de restricción inválido: %s", restriccion.Tipo)
+	}
+
+	return r.db.Create(restriccion).Error
+}
+
+func (r *SoDRepository) GetAll() ([]models.RestriccionSoD, error) {
+	var restricciones []models.RestriccionSoD
+	err := r.db.Find(&restricciones).Error
+	return restricciones, err
+}
+
+func (r *SoDRepository) Delete(id int) error {
+	result := r.db.Delete(&models.RestriccionSoD{}, id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("restricción no encontrada")
+	}
+	return nil
+}
+
+// GetViolations lista los roles que hoy incumplen alguna restricción, ya sea
+// directamente o a través de los roles de los que heredan
+func (r *SoDRepository) GetViolations() ([]models.ViolacionSoD, error) {
+	var restricciones []models.RestriccionSoD
+	if err := r.db.Find(&restricciones).Error; err != nil {
+		return nil, err
+	}
+
+	violaciones := make([]models.ViolacionSoD, 0)
+	if len(restricciones) == 0 {
+		return violaciones, nil
+	}
+
+	var roles []models.Role
+	if err := r.db.Where("fecha_eliminacion IS NULL").Find(&roles).Error; err != nil {
+		return nil, err
+	}
+
+	for _, role := range roles {
+		ancestorIDs, err := roleAncestorIDs(r.db, role.ID)
+		if err != nil {
+			return nil, err
+		}
+
+		violadas, err := sodViolations(r.db, append([]int{role.ID}, ancestorIDs...), nil, restricciones)
+		if err != nil {
+			return nil, err
+		}
+		if len(violadas) == 0 {
+			continue
+		}
+
+		var usuarios []int
+		if err := r.db.Model(&models.User{}).Where("id_rol = ? AND fecha_eliminacion IS NULL", role.ID).Pluck("id", &usuarios).Error; err != nil {
+			return nil, err
+		}
+
+		for _, restriccion := range violadas {
+			violaciones = append(violaciones, models.ViolacionSoD{
+				IdRestriccion: restriccion.ID,
+				Restriccion:   restriccion.Nombre,
+				Tipo:          restriccion.Tipo,
+				IdRol:         role.ID,
+				Rol:           role.Nombre,
+				Usuarios:      usuarios,
+			})
+		}
+	}
+
+	// Usuarios cuyos grupos les aportan roles o permisos adicionales
+	var miembros []models.User
+	if err := r.db.Where("id IN (?) AND fecha_eliminacion IS NULL", r.db.Model(&models.GrupoUsuario{}).Select("id_usuario")).
+		Preload("Role").
+		Find(&miembros).Error; err != nil {
+		return nil, err
+	}
+	for _, user := range miembros {
+		roleIDs, grupoIDs, err := userSoDSet(r.db, user.ID, user.IdRol)
+		if err != nil {
+			return nil, err
+		}
+		violadas, err := sodViolations(r.db, roleIDs, grupoIDs, restricciones)
+		if err != nil {
+			return nil, err
+		}
+		for _, restriccion := range violadas {
+			if yaReportada(violaciones, restriccion.ID, user.IdRol) {
+				continue
+			}
+			violaciones = append(violaciones, models.ViolacionSoD{
+				IdRestriccion: restriccion.ID,
+				Restriccion:   restriccion.Nombre,
+				Tipo:          restriccion.Tipo,
+				IdRol:         user.IdRol,
+				Rol:           user.Role.Nombre,
+				Usuarios:      []int{user.ID},
+				Grupos:        grupoIDs,
+			})
+		}
+	}
+
+	return violaciones, nil
+}
+
+// yaReportada indica si la violación ya figura a nivel del rol, en cuyo caso
+// el usuario está incluido en ella
+func yaReportada(violaciones []models.ViolacionSoD, restriccionID, roleID int) bool {
+	for _, v := range violaciones {
+		if v.IdRestriccion == restriccionID && v.IdRol == roleID && len(v.Grupos) == 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// checkSoD devuelve un error si el conjunto de roles y grupos (con sus permisos
+// efectivos) incumple alguna restricción de separación de funciones
+func checkSoD(db *gorm.DB, roleIDs, grupoIDs []int) error {
+	var restricciones []models.RestriccionSoD
+	if err := db.Find(&restricciones).Error; err != nil {
+		return err
+	}
+	if len(restricciones) == 0 {
+		return nil
+	}
+
+	violadas, err := sodViolations(db, roleIDs, grupoIDs, restricciones)
+	if err != nil {
+		return err
+	}
+	if len(violadas) > 0 {
+		return fmt.Errorf("la asignación viola la restricción de separación de funciones '%s'", violadas[0].Nombre)
+	}
+	return nil
+}
+
+// checkRoleTreeSoD valida un rol y todos los roles que heredan de él, ya que
+// un cambio en sus permisos o padres también les afecta, junto con los
+// usuarios que combinan esos roles con los de sus grupos
+func checkRoleTreeSoD(db *gorm.DB, roleID int) error {
+	descendantIDs, err := roleDescendantIDs(db, roleID)
+	if err != nil {
+		return err
+	}
+	tree := append([]int{roleID}, descendantIDs...)
+
+	for _, id := range tree {
+		ancestorIDs, err := roleAncestorIDs(db, id)
+		if err != nil {
+			return err
+		}
+		if err := checkSoD(db, append([]int{id}, ancestorIDs...), nil); err != nil {
+			return err
+		}
+	}
+
+	var users []models.User
+	if err := db.Where("id IN (?) AND fecha_eliminacion IS NULL", db.Model(&models.GrupoUsuario{}).Select("id_usuario")).
+		Where("id_rol IN ? OR id IN (?)", tree,
+			db.Model(&models.GrupoUsuario{}).
+				Joins("JOIN grupo_roles ON grupo_roles.id_grupo = grupo_usuarios.id_grupo").
+				Where("grupo_roles.id_rol IN ?", tree).
+				Select("grupo_usuarios.id_usuario")).
+		Find(&users).Error; err != nil {
+		return err
+	}
+	for _, user := range users {
+		if err := checkUserSoD(db, user.ID, user.IdRol); err != nil {
+			return fmt.Errorf("usuario %d: %v", user.ID, err)
+		}
+	}
+	return nil
+}
+
+func sodViolations(db *gorm.DB, roleIDs, grupoIDs []int, restricciones []models.RestriccionSoD) ([]models.RestriccionSoD, error) {
+	roles := make(map[int]bool, len(roleIDs))
+	for _, id := range roleIDs {
+		roles[id] = true
+	}
+
+	// Permisos concedidos al conjunto de roles, descontando las denegaciones globales.
+	// Se consideran todas las asignaciones activas, incluso las de validez futura.
+	grants, err := activeGrants(db, roleIDs, nil)
+	if err != nil {
+		return nil, err
+	}
+	permitidos := make(map[[2]int]bool)
+	denegados := make(map[[2]int]bool)
+	for _, g := range grants {
+		key := [2]int{*g.IdModulo, *g.IdPermisoTipo}
+		if g.Efecto == models.EfectoDenegar {
+			if g.Alcance == models.AlcanceGlobal {
+				denegados[key] = true
+			}
+			continue
+		}
+		permitidos[key] = true
+	}
+
+	// Permisos directos de los grupos
+	grupoPermisos, err := activeGroupGrants(db, grupoIDs)
+	if err != nil {
+		return nil, err
+	}
+	for _, gp := range grupoPermisos {
+		key := [2]int{gp.IdModulo, gp.IdPermisoTipo}
+		if gp.Efecto == models.EfectoDenegar {
+			denegados[key] = true
+			continue
+		}
+		permitidos[key] = true
+	}
+	tiene := func(moduloID, permisoTipoID *int) bool {
+		key := [2]int{*moduloID, *permisoTipoID}
+		return permitidos[key] && !denegados[key]
+	}
+
+	violadas := make([]models.RestriccionSoD, 0)
+	for _, restriccion := range restricciones {
+		switch restriccion.Tipo {
+		case models.SoDTipoRoles:
+			if roles[*restriccion.IdRolA] && roles[*restriccion.IdRolB] {
+				violadas = append(violadas, restriccion)
+			}
+		case models.SoDTipoPermisos:
+			if tiene(restriccion.IdModuloA, restriccion.IdPermisoTipoA) &&
+				tiene(restriccion.IdModuloB, restriccion.IdPermisoTipoB) {
+				violadas = append(violadas, restriccion)
+			}
+		}
+	}
+
+	return violadas, nil
+}
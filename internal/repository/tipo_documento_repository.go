@@ -0,0 +1,109 @@
+package repository
+
+import (
+	"auth-service/internal/models"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+type TipoDocumentoRepository struct {
+	db *gorm.DB
+}
+
+func NewTipoDocumentoRepository(db *gorm.DB) *TipoDocumentoRepository {
+	return &TipoDocumentoRepository{db: db}
+}
+
+func (r *TipoDocumentoRepository) Create(tipo *models.TipoDocumento) error {
+	tipo.Codigo = strings.ToUpper(strings.TrimSpace(tipo.Codigo))
+	if tipo.Codigo == "" {
+		return fmt.Errorf("el código del tipo de documento es obligatorio")
+	}
+	if _, err := regexp.Compile(tipo.Patron); err != nil {
+		return fmt.Errorf("patrón inválido: %v", err)
+	}
+
+	var exists bool
+	if err := r.db.Model(&models.TipoDocumento{}).
+		Where("codigo = ?", tipo.Codigo).
+		Select("count(*) > 0").
+		Scan(&exists).Error; err != nil {
+		return err
+	}
+	if exists {
+		return fmt.Errorf("ya existe un tipo de documento con este código")
+	}
+
+	tipo.Activo = true
+	return r.db.Create(tipo).Error
+}
+
+// GetAll devuelve los tipos de documento; con soloActivos omite los retirados
+func (r *TipoDocumentoRepository) GetAll(soloActivos bool) ([]models.TipoDocumento, error) {
+	var tipos []models.TipoDocumento
+	query := r.db.Order("codigo")
+	if soloActivos {
+		query = query.Where("activo")
+	}
+	err := query.Find(&tipos).Error
+	return tipos, err
+}
+
+func (r *TipoDocumentoRepository) GetByID(id int) (*models.TipoDocumento, error) {
+	var tipo models.TipoDocumento
+	if err := r.db.First(&tipo, id).Error; err != nil {
+		return nil, fmt.Errorf("tipo de documento no encontrado: %v", err)
+	}
+	return &tipo, nil
+}
+
+// Update cambia las reglas del tipo. Las nuevas reglas se aplican a los
+// usuarios que se creen o actualicen después; los existentes no se revalidan.
+func (r *TipoDocumentoRepository) Update(id int, req models.UpdateTipoDocumentoRequest) (*models.TipoDocumento, error) {
+	tipo, err := r.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := regexp.Compile(req.Patron); err != nil {
+		return nil, fmt.Errorf("patrón inválido: %v", err)
+	}
+
+	cambios := map[string]interface{}{
+		"nombre":              req.Nombre,
+		"patron":              req.Patron,
+		"longitud_minima":     req.LongitudMinima,
+		"longitud_maxima":     req.LongitudMaxima,
+		"digito_verificacion": req.DigitoVerificacion,
+	}
+	if req.Activo != nil {
+		cambios["activo"] = *req.Activo
+	}
+	if err := r.db.Model(tipo).Updates(cambios).Error; err != nil {
+		return nil, err
+	}
+	return r.GetByID(id)
+}
+
+// validarDocumento comprueba el documento del usuario contra el catálogo y
+// guarda el código del tipo y el número normalizados
+func validarDocumento(db *gorm.DB, user *models.User) error {
+	codigo := strings.ToUpper(strings.TrimSpace(user.TipoDocumento))
+	var tipo models.TipoDocumento
+	if err := db.Where("codigo = ? AND activo", codigo).First(&tipo).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return fmt.Errorf("tipo de documento no válido: %s", user.TipoDocumento)
+		}
+		return err
+	}
+
+	numero, err := tipo.ValidarNumero(user.NumeroDocumento)
+	if err != nil {
+		return err
+	}
+	user.TipoDocumento = tipo.Codigo
+	user.NumeroDocumento = numero
+	return nil
+}
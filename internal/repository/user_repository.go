@@ -2,9 +2,13 @@ package repository
 
 import (
 	"auth-service/internal/models"
+	"context"
 	"fmt"
 	"regexp"
+	"slices"
+	"time"
 
+	"golang.org/x/crypto/bcrypt"
 	"gorm.io/gorm"
 )
 
@@ -16,42 +20,133 @@ func NewUserRepository(db *gorm.DB) *UserRepository {
 	return &UserRepository{db: db}
 }
 
-func (r *UserRepository) Create(user *models.User) error {
-	// Validar que número de documento sean solo dígitos
-	if !regexp.MustCompile(`^\d+$`).MatchString(user.NumeroDocumento) {
-		return fmt.Errorf("el número de documento debe contener solo números")
+// WithContext devuelve una copia del repositorio cuyas consultas usan el
+// contexto indicado, incluida la organización de la petición
+func (r *UserRepository) WithContext(ctx context.Context) *UserRepository {
+	return &UserRepository{db: r.db.WithContext(ctx)}
+}
+
+// Create registra un usuario. Si existe un usuario eliminado con el mismo
+// documento, se reactiva ese registro con los datos nuevos en lugar de crear
+// otro, y se devuelve true. Una reactivación es un nuevo ingreso: el usuario
+// no recupera los grupos que tenía.
+func (r *UserRepository) Create(user *models.User) (bool, error) {
+	if err := validateVentana(user.RolValidoDesde, user.RolValidoHasta); err != nil {
+		return false, err
+	}
+
+	// Validar el número de documento con las reglas de su tipo
+	if err := validarDocumento(r.db, user); err != nil {
+		return false, err
 	}
 
 	// Validar que teléfono sean solo dígitos
 	if !regexp.MustCompile(`^\d+$`).MatchString(user.Telefono) {
-		return fmt.Errorf("el teléfono debe contener solo números")
+		return false, fmt.Errorf("el teléfono debe contener solo números")
 	}
 
-	// Verificar si existe por documento
-	var exists bool
-	if err := r.db.Model(&models.User{}).
-		Where("tipo_documento = ? AND numero_documento = ?", user.TipoDocumento, user.NumeroDocumento).
-		Select("count(*) > 0").
-		Scan(&exists).Error; err != nil {
-		return err
+	// Verificar si existe por documento, incluidos los usuarios eliminados
+	var existente models.User
+	err := r.db.Where("tipo_documento = ? AND numero_documento = ?", user.TipoDocumento, user.NumeroDocumento).
+		First(&existente).Error
+	if err != nil && err != gorm.ErrRecordNotFound {
+		return false, err
+	}
+	reactivar := err == nil
+	if reactivar && existente.FechaEliminacion == nil {
+		return false, fmt.Errorf("ya existe un usuario con este documento")
+	}
+
+	// El correo no puede pertenecer a otro usuario, aunque esté eliminado
+	var otro models.User
+	err = r.db.Where("correo = ? AND id != ?", user.Correo, existente.ID).First(&otro).Error
+	if err == nil {
+		if otro.FechaEliminacion != nil {
+			return false, fmt.Errorf("el correo pertenece al usuario eliminado %d", otro.ID)
+		}
+		return false, fmt.Errorf("ya existe un usuario con este correo")
+	}
+	if err != gorm.ErrRecordNotFound {
+		return false, err
+	}
+
+	if err := r.db.Where("fecha_eliminacion IS NULL").First(&models.Role{}, user.IdRol).Error; err != nil {
+		return false, fmt.Errorf("rol no encontrado: %v", err)
+	}
+	if err := asignarSede(r.db, user); err != nil {
+		return false, err
+	}
+
+	// Validar separación de funciones del rol asignado
+	if err := checkUserSoD(r.db, 0, user.IdRol); err != nil {
+		return false, err
 	}
-	if exists {
-		return fmt.Errorf("ya existe un usuario con este documento")
+
+	if !reactivar {
+		return false, r.db.Create(user).Error
 	}
 
-	// Resto del código existente...
-	return r.db.Create(user).Error
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(user.Contraseña), bcrypt.DefaultCost)
+	if err != nil {
+		return false, err
+	}
+	user.ID = existente.ID
+	user.Contraseña = string(hashedPassword)
+
+	err = r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("id_usuario = ?", user.ID).Delete(&models.GrupoUsuario{}).Error; err != nil {
+			return err
+		}
+
+		return tx.Model(&existente).Select(
+			"nombre",
+			"apellidos",
+			"sede",
+			"id_sede",
+			"regional",
+			"id_regional",
+			"correo",
+			"telefono",
+			"contraseña",
+			"id_rol",
+			"rol_valido_desde",
+			"rol_valido_hasta",
+			"estado",
+			"fecha_eliminacion",
+		).Updates(map[string]interface{}{
+			"nombre":            user.Nombre,
+			"apellidos":         user.Apellidos,
+			"sede":              user.Sede,
+			"id_sede":           user.IdSede,
+			"regional":          user.Regional,
+			"id_regional":       user.IdRegional,
+			"correo":            user.Correo,
+			"telefono":          user.Telefono,
+			"contraseña":        user.Contraseña,
+			"id_rol":            user.IdRol,
+			"rol_valido_desde":  user.RolValidoDesde,
+			"rol_valido_hasta":  user.RolValidoHasta,
+			"estado":            user.Estado,
+			"fecha_eliminacion": nil,
+		}).Error
+	})
+	return true, err
 }
 
-func (r *UserRepository) GetAll() ([]models.User, error) {
+// GetAll devuelve los usuarios no eliminados, opcionalmente solo los de un estado
+func (r *UserRepository) GetAll(estado string) ([]models.User, error) {
 	var users []models.User
-	err := r.db.Preload("Role").Find(&users).Error
+	query := r.db.Preload("Role").Where("fecha_eliminacion IS NULL")
+	if estado != "" {
+		query = query.Where("estado = ?", estado)
+	}
+	err := query.Find(&users).Error
 	return users, err
 }
 
 func (r *UserRepository) GetByID(id int) (*models.User, error) {
 	var user models.User
-	err := r.db.Preload("Role").First(&user, id).Error
+	err := r.db.Preload("Role").Where("fecha_eliminacion IS NULL").First(&user, id).Error
 	if err != nil {
 		return nil, fmt.Errorf("usuario no encontrado: %v", err)
 	}
@@ -60,6 +155,11 @@ func (r *UserRepository) GetByID(id int) (*models.User, error) {
 
 func (r *UserRepository) Update(user *models.User) error {
 	return r.db.Transaction(func(tx *gorm.DB) error {
+		// Validar el número de documento con las reglas de su tipo
+		if err := validarDocumento(tx, user); err != nil {
+			return err
+		}
+
 		// Verificar si el documento ya existe para otro usuario
 		var count int64
 		if err := tx.Model(&models.User{}).
@@ -82,16 +182,27 @@ func (r *UserRepository) Update(user *models.User) error {
 			return fmt.Errorf("el correo ya está en uso por otro usuario")
 		}
 
-		// Validar que número de documento sean solo dígitos
-		if !regexp.MustCompile(`^\d+$`).MatchString(user.NumeroDocumento) {
-			return fmt.Errorf("el número de documento debe contener solo números")
-		}
-
 		// Validar que teléfono sean solo dígitos
 		if !regexp.MustCompile(`^\d+$`).MatchString(user.Telefono) {
 			return fmt.Errorf("el teléfono debe contener solo números")
 		}
 
+		if err := validateVentana(user.RolValidoDesde, user.RolValidoHasta); err != nil {
+			return err
+		}
+
+		if err := tx.Where("fecha_eliminacion IS NULL").First(&models.Role{}, user.IdRol).Error; err != nil {
+			return fmt.Errorf("rol no encontrado: %v", err)
+		}
+		if err := asignarSede(tx, user); err != nil {
+			return err
+		}
+
+		// Validar separación de funciones del rol asignado
+		if err := checkUserSoD(tx, user.ID, user.IdRol); err != nil {
+			return err
+		}
+
 		// Actualizar todos los campos permitidos
 		result := tx.Model(user).Select(
 			"nombre",
@@ -99,20 +210,28 @@ func (r *UserRepository) Update(user *models.User) error {
 			"tipo_documento",
 			"numero_documento",
 			"sede",
+			"id_sede",
 			"regional",
+			"id_regional",
 			"correo",
 			"telefono",
 			"id_rol",
+			"rol_valido_desde",
+			"rol_valido_hasta",
 		).Updates(map[string]interface{}{
 			"nombre":           user.Nombre,
 			"apellidos":        user.Apellidos,
 			"tipo_documento":   user.TipoDocumento,
 			"numero_documento": user.NumeroDocumento,
 			"sede":             user.Sede,
+			"id_sede":          user.IdSede,
 			"regional":         user.Regional,
+			"id_regional":      user.IdRegional,
 			"correo":           user.Correo,
 			"telefono":         user.Telefono,
 			"id_rol":           user.IdRol,
+			"rol_valido_desde": user.RolValidoDesde,
+			"rol_valido_hasta": user.RolValidoHasta,
 		})
 
 		if result.Error != nil {
@@ -128,27 +247,119 @@ func (r *UserRepository) Update(user *models.User) error {
 	})
 }
 
+// Delete marca el usuario como eliminado; conserva su registro, sus grupos y
+// su historial para que pueda restaurarse
 func (r *UserRepository) Delete(id int) error {
-	return r.db.Delete(&models.User{}, id).Error
+	result := r.db.Model(&models.User{}).
+		Where("id = ? AND fecha_eliminacion IS NULL", id).
+		Update("fecha_eliminacion", time.Now())
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("usuario no encontrado o ya está eliminado")
+	}
+	return nil
+}
+
+// Restore reactiva un usuario eliminado tal como estaba
+func (r *UserRepository) Restore(id int) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var user models.User
+		if err := tx.First(&user, id).Error; err != nil {
+			return fmt.Errorf("usuario no encontrado")
+		}
+
+		if user.FechaEliminacion == nil {
+			return fmt.Errorf("el usuario no está eliminado")
+		}
+
+		if err := tx.Where("fecha_eliminacion IS NULL").First(&models.Role{}, user.IdRol).Error; err != nil {
+			return fmt.Errorf("el rol %d del usuario está eliminado; asígnele otro rol al reactivarlo", user.IdRol)
+		}
+
+		if err := checkUserSoD(tx, user.ID, user.IdRol); err != nil {
+			return err
+		}
+
+		return tx.Model(&user).Update("fecha_eliminacion", nil).Error
+	})
+}
+
+// ChangeEstado aplica una transición de estado permitida y la registra en el
+// historial con su motivo y el usuario que la realizó
+func (r *UserRepository) ChangeEstado(id int, req models.CambiarEstadoUsuarioRequest) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var user models.User
+		if err := tx.Where("fecha_eliminacion IS NULL").First(&user, id).Error; err != nil {
+			return fmt.Errorf("usuario no encontrado: %v", err)
+		}
+
+		var actor models.User
+		if err := tx.Where("fecha_eliminacion IS NULL").First(&actor, req.IdActor).Error; err != nil {
+			return fmt.Errorf("actor no encontrado: %v", err)
+		}
+		if !actor.Activo() {
+			return fmt.Errorf("el actor debe tener una cuenta activa")
+		}
+
+		if user.Estado == req.Estado {
+			return fmt.Errorf("el usuario ya está en estado %s", req.Estado)
+		}
+		if !models.TransicionPermitida(user.Estado, req.Estado) {
+			return fmt.Errorf("no se permite pasar de %s a %s", user.Estado, req.Estado)
+		}
+
+		if err := tx.Model(&user).Update("estado", req.Estado).Error; err != nil {
+			return err
+		}
+
+		return tx.Create(&models.UsuarioEstadoHistorial{
+			IdUsuario:      id,
+			EstadoAnterior: user.Estado,
+			EstadoNuevo:    req.Estado,
+			Motivo:         req.Motivo,
+			IdActor:        req.IdActor,
+		}).Error
+	})
+}
+
+func (r *UserRepository) GetEstadoHistorial(id int) ([]models.UsuarioEstadoHistorial, error) {
+	if err := r.db.First(&models.User{}, id).Error; err != nil {
+		return nil, fmt.Errorf("usuario no encontrado: %v", err)
+	}
+
+	var historial []models.UsuarioEstadoHistorial
+	err := r.db.Where("id_usuario = ?", id).Order("fecha DESC, id DESC").Find(&historial).Error
+	return historial, err
+}
+
+func (r *UserRepository) GetDeletedUsers() ([]models.User, error) {
+	var users []models.User
+	err := r.db.Preload("Role").
+		Where("fecha_eliminacion IS NOT NULL").
+		Order("fecha_eliminacion DESC").
+		Find(&users).Error
+	return users, err
 }
 
 func (r *UserRepository) ExistsByEmail(email string) (bool, error) {
 	var count int64
-	err := r.db.Model(&models.User{}).Where("correo = ?", email).Count(&count).Error
+	err := r.db.Model(&models.User{}).Where("correo = ? AND fecha_eliminacion IS NULL", email).Count(&count).Error
 	return count > 0, err
 }
 
 func (r *UserRepository) ExistsByDocumento(tipoDoc, numDoc string) (bool, error) {
 	var count int64
 	err := r.db.Model(&models.User{}).
-		Where("tipo_documento = ? AND numero_documento = ?", tipoDoc, numDoc).
+		Where("tipo_documento = ? AND numero_documento = ? AND fecha_eliminacion IS NULL", tipoDoc, numDoc).
 		Count(&count).Error
 	return count > 0, err
 }
 
 func (r *UserRepository) GetByEmail(email string) (*models.User, error) {
 	var user models.User
-	err := r.db.Where("correo = ?", email).First(&user).Error
+	err := r.db.Where("correo = ? AND fecha_eliminacion IS NULL", email).First(&user).Error
 	if err != nil {
 		return nil, fmt.Errorf("usuario no encontrado: %v", err)
 	}
@@ -157,7 +368,7 @@ func (r *UserRepository) GetByEmail(email string) (*models.User, error) {
 
 func (r *UserRepository) GetByDocumento(tipoDoc, numDoc string) (*models.User, error) {
 	var user models.User
-	err := r.db.Where("tipo_documento = ? AND numero_documento = ?", tipoDoc, numDoc).First(&user).Error
+	err := r.db.Where("tipo_documento = ? AND numero_documento = ? AND fecha_eliminacion IS NULL", tipoDoc, numDoc).First(&user).Error
 	if err != nil {
 		return nil, fmt.Errorf("usuario no encontrado: %v", err)
 	}
@@ -166,37 +377,34 @@ func (r *UserRepository) GetByDocumento(tipoDoc, numDoc string) (*models.User, e
 
 func (r *UserRepository) GetByRoleID(roleID int) ([]models.User, error) {
 	var users []models.User
-	err := r.db.Where("id_rol = ?", roleID).Find(&users).Error
+	err := r.db.Where("id_rol = ? AND fecha_eliminacion IS NULL", roleID).Find(&users).Error
 	return users, err
 }
 
 func (r *UserRepository) UpdatePassword(id int, hashedPassword string) error {
-	return r.db.Model(&models.User{}).Where("id = ?", id).Update("contraseña", hashedPassword).Error
+	return r.db.Model(&models.User{}).Where("id = ? AND fecha_eliminacion IS NULL", id).Update("contraseña", hashedPassword).Error
 }
 
-// Nuevo método para obtener un usuario con sus permisos
-func (r *UserRepository) GetUserWithPermissions(id int) (*models.User, []models.RolModuloPermiso, error) {
+// GetUserWithPermissions devuelve el usuario con sus permisos efectivos por
+// módulo, resueltos igual que en GetUserPermissions
+func (r *UserRepository) GetUserWithPermissions(id int) (*models.User, []models.ModuloPermissions, error) {
 	var user models.User
-	err := r.db.Preload("Role").First(&user, id).Error
+	err := r.db.Preload("Role").Where("fecha_eliminacion IS NULL").First(&user, id).Error
 	if err != nil {
 		return nil, nil, fmt.Errorf("usuario no encontrado: %v", err)
 	}
 
-	var permissions []models.RolModuloPermiso
-	err = r.db.Where("id_rol = ?", user.IdRol).
-		Preload("Modulo").
-		Preload("PermisoTipo").
-		Find(&permissions).Error
+	permisos, err := resolveUserPermissions(r.db, &user, time.Now())
 	if err != nil {
 		return nil, nil, fmt.Errorf("error al obtener permisos: %v", err)
 	}
 
-	return &user, permissions, nil
+	return &user, permisos.lista(), nil
 }
 
 func (r *UserRepository) GetAllUsersWithPermissions() (*models.UsersPermissionsListResponse, error) {
 	var users []models.User
-	if err := r.db.Preload("Role").Find(&users).Error; err != nil {
+	if err := r.db.Preload("Role").Where("fecha_eliminacion IS NULL").Find(&users).Error; err != nil {
 		return nil, err
 	}
 
@@ -218,41 +426,15 @@ func (r *UserRepository) GetAllUsersWithPermissions() (*models.UsersPermissionsL
 
 func (r *UserRepository) GetUserPermissions(userID int) (*models.UserPermissionsResponse, error) {
 	var user models.User
-	if err := r.db.Preload("Role").First(&user, userID).Error; err != nil {
+	if err := r.db.Preload("Role").Where("fecha_eliminacion IS NULL").First(&user, userID).Error; err != nil {
 		return nil, err
 	}
 
-	// Obtener permisos por módulo
-	var rolModuloPermisos []models.RolModuloPermiso
-	if err := r.db.Where("id_rol = ?", user.IdRol).
-		Preload("Modulo").
-		Preload("PermisoTipo").
-		Find(&rolModuloPermisos).Error; err != nil {
+	permisos, err := resolveUserPermissions(r.db, &user, time.Now())
+	if err != nil {
 		return nil, err
 	}
 
-	// Organizar permisos por módulo
-	moduloPermisos := make(map[int]*models.ModuloPermissions)
-	for _, rmp := range rolModuloPermisos {
-		if _, exists := moduloPermisos[rmp.IdModulo]; !exists {
-			moduloPermisos[rmp.IdModulo] = &models.ModuloPermissions{
-				ID:       rmp.Modulo.ID,
-				Nombre:   rmp.Modulo.Nombre,
-				Permisos: make([]string, 0),
-			}
-		}
-		moduloPermisos[rmp.IdModulo].Permisos = append(
-			moduloPermisos[rmp.IdModulo].Permisos,
-			rmp.PermisoTipo.Codigo,
-		)
-	}
-
-	// Convertir map a slice
-	modulePermsList := make([]models.ModuloPermissions, 0, len(moduloPermisos))
-	for _, mp := range moduloPermisos {
-		modulePermsList = append(modulePermsList, *mp)
-	}
-
 	return &models.UserPermissionsResponse{
 		ID:              user.ID,
 		Nombre:          user.Nombre,
@@ -262,10 +444,64 @@ func (r *UserRepository) GetUserPermissions(userID int) (*models.UserPermissions
 		Correo:          user.Correo,
 		Sede:            user.Sede,
 		Regional:        user.Regional,
+		Estado:          user.Estado,
 		Role: models.RolePermissions{
 			ID:             user.Role.ID,
 			Nombre:         user.Role.Nombre,
-			ModuloPermisos: modulePermsList,
+			ModuloPermisos: permisos.lista(),
 		},
 	}, nil
 }
+
+// checkUserSoD valida que los roles efectivos de un usuario (el rol indicado,
+// los roles de sus grupos y los ancestros de todos ellos) y los permisos
+// directos de sus grupos no incumplan ninguna restricción de separación de
+// funciones. Las ventanas de validez no se consideran.
+func checkUserSoD(db *gorm.DB, userID, roleID int) error {
+	roleIDs, grupoIDs, err := userSoDSet(db, userID, roleID)
+	if err != nil {
+		return err
+	}
+	return checkSoD(db, roleIDs, grupoIDs)
+}
+
+func userSoDSet(db *gorm.DB, userID, roleID int) ([]int, []int, error) {
+	roleIDs := []int{roleID}
+	var grupoIDs []int
+	if userID != 0 {
+		var err error
+		if grupoIDs, err = userGroupIDs(db, userID); err != nil {
+			return nil, nil, err
+		}
+		if len(grupoIDs) > 0 {
+			var grupoRoleIDs []int
+			if err := db.Model(&models.GrupoRol{}).Where("id_grupo IN ?", grupoIDs).
+				Pluck("id_rol", &grupoRoleIDs).Error; err != nil {
+				return nil, nil, err
+			}
+			roleIDs = append(roleIDs, grupoRoleIDs...)
+		}
+	}
+
+	todos := make([]int, 0, len(roleIDs))
+	for _, id := range roleIDs {
+		ancestorIDs, err := roleAncestorIDs(db, id)
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, rid := range append([]int{id}, ancestorIDs...) {
+			if !slices.Contains(todos, rid) {
+				todos = append(todos, rid)
+			}
+		}
+	}
+	return todos, grupoIDs, nil
+}
+
+// usuariosActivos es una subconsulta con los ids de los usuarios no eliminados
+// y en estado activo
+func usuariosActivos(db *gorm.DB) *gorm.DB {
+	return db.Model(&models.User{}).
+		Where("fecha_eliminacion IS NULL AND estado = ?", models.EstadoActivo).
+		Select("id")
+}
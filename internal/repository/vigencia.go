@@ -0,0 +1,25 @@
+package repository
+
+import (
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// validateVentana comprueba que una ventana de validez opcional sea coherente
+func validateVentana(desde, hasta *time.Time) error {
+	if desde != nil && hasta != nil && !hasta.After(*desde) {
+		return fmt.Errorf("la fecha de fin de validez debe ser posterior a la fecha de inicio")
+	}
+	return nil
+}
+
+// grantVigente limita la consulta a las asignaciones de rol_modulo_permisos
+// cuya ventana de validez incluye el instante indicado
+func grantVigente(now time.Time) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("(rol_modulo_permisos.valido_desde IS NULL OR rol_modulo_permisos.valido_desde <= ?)", now).
+			Where("(rol_modulo_permisos.valido_hasta IS NULL OR rol_modulo_permisos.valido_hasta > ?)", now)
+	}
+}